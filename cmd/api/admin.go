@@ -1,22 +1,30 @@
 package main
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
 	"golang.org/x/crypto/bcrypt"
 )
 
+type adminCreateUserRequest struct {
+	createUserRequest
+	SkipEmailVerification bool `json:"skip_email_verification"` // mark the email as verified instead of sending a link
+}
+
 // CreateUserManually godoc
 //
 //	@Summary		Create user manually
-//	@Description	Create a new user
+//	@Description	Create a new user. The user is emailed a verification link unless skip_email_verification is set
 //	@Tags			Admin
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		createUserRequest	true	"User payload"
+//	@Param			payload	body		adminCreateUserRequest	true	"User payload"
 //	@Success		201		{object}	userResponse
 //	@Failure		400		{object}	error
 //	@Failure		404		{object}	error
@@ -25,7 +33,7 @@ import (
 //	@Security		BearerAuth
 func (app *application) adminCreateUser(c *gin.Context) {
 
-	var payload createUserRequest
+	var payload adminCreateUserRequest
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -48,18 +56,28 @@ func (app *application) adminCreateUser(c *gin.Context) {
 		Password: string(hashedPassword),
 	}
 
+	if payload.SkipEmailVerification {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
 	createdUser, err := app.store.Users.CreateUser(c.Request.Context(), user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if createdUser.EmailVerifiedAt == nil {
+		go app.sendEmailVerification(createdUser)
+	}
+
 	c.JSON(http.StatusCreated, userResponse{
-		ID:        createdUser.ID,
-		Username:  createdUser.Username,
-		Email:     createdUser.Email,
-		Role:      createdUser.Role,
-		CreatedAt: createdUser.CreatedAt.Format(time.RFC3339),
+		ID:            createdUser.ID,
+		Username:      createdUser.Username,
+		Email:         createdUser.Email,
+		Role:          createdUser.Role,
+		EmailVerified: createdUser.EmailVerifiedAt != nil,
+		CreatedAt:     createdUser.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -129,11 +147,12 @@ func (app *application) adminUpdateProfile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, userResponse{
-		ID:        updatedUser.ID,
-		Username:  updatedUser.Username,
-		Email:     updatedUser.Email,
-		Role:      updatedUser.Role,
-		CreatedAt: updatedUser.CreatedAt.Format(time.RFC3339),
+		ID:            updatedUser.ID,
+		Username:      updatedUser.Username,
+		Email:         updatedUser.Email,
+		Role:          updatedUser.Role,
+		EmailVerified: updatedUser.EmailVerifiedAt != nil,
+		CreatedAt:     updatedUser.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -173,22 +192,30 @@ func (app *application) getUserById(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, userResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
 // Getusersgodoc
 //
 //	@Summary		Get Users
-//	@Description	Get All Users
+//	@Description	List users with keyset pagination, filters and sorting
 //	@Tags			Admin
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	userResponse
+//	@Param			limit			query		int		false	"Page size (default 50, max 200)"
+//	@Param			cursor			query		string	false	"next_cursor of the previous page"
+//	@Param			sort			query		string	false	"created_at, username or email; prefix with - for descending (default -created_at)"
+//	@Param			role			query		string	false	"Filter by role"
+//	@Param			q				query		string	false	"Search username and email"
+//	@Param			created_from	query		string	false	"Created at or after (date or RFC 3339)"
+//	@Param			created_to		query		string	false	"Created before (date or RFC 3339)"
+//	@Success		200				{object}	listUsersResponse
 //	@Failure		400	{object}	error
 //	@Failure		404	{object}	error
 //	@Failure		500	{object}	error
@@ -208,24 +235,30 @@ func (app *application) getUsers(c *gin.Context) {
 		return
 	}
 
-	users, err := app.store.Users.GetAllUsers(c.Request.Context())
+	q, ok := parseListQuery(c, store.UserListSpec)
+	if !ok {
+		return
+	}
+
+	users, page, err := app.store.Users.GetAllUsers(c.Request.Context(), q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve users"})
 		return
 	}
 
-	var response []userResponse
+	response := []userResponse{}
 	for _, user := range *users {
 		response = append(response, userResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			Role:      user.Role,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
+			ID:            user.ID,
+			Username:      user.Username,
+			Email:         user.Email,
+			Role:          user.Role,
+			EmailVerified: user.EmailVerifiedAt != nil,
+			CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 		})
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listUsersResponse{Data: response, Pagination: page})
 }
 
 // DeleteuserById godoc
@@ -264,3 +297,120 @@ func (app *application) adminDeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, "user deleted successfully")
 }
+
+// RevokeUserTokens godoc
+//
+//	@Summary		Revoke user tokens
+//	@Description	Sign a user out everywhere: access tokens issued until now are rejected and every refresh token and API key is revoked
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	string	"tokens revoked"
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/user/{id}/revoke-tokens [post]
+//
+//	@Security		BearerAuth
+func (app *application) adminRevokeUserTokens(c *gin.Context) {
+
+	if err := app.store.RevokedTokens.RevokeAllUserTokens(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "user tokens revoked successfully")
+}
+
+// ResetUserMFA godoc
+//
+//	@Summary		Reset user two-factor authentication
+//	@Description	Remove a user's authenticator and recovery codes, e.g. after they lost both. The user can enroll again
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	string	"two-factor authentication reset"
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/user/{id}/mfa [delete]
+//
+//	@Security		BearerAuth
+func (app *application) adminResetUserMFA(c *gin.Context) {
+
+	if err := app.store.MFA.DisableMFA(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, store.ErrMFANotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "two-factor authentication reset successfully")
+}
+
+type adminUnlockRequest struct {
+	IP string `json:"ip" binding:"omitempty,ip"` // also lift the lock on this client IP
+}
+
+// UnlockUser godoc
+//
+//	@Summary		Unlock user login
+//	@Description	Clear a user's failed login and two-factor attempts and lift their lockout, and optionally the lockout of a client IP
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"User ID"
+//	@Param			payload	body		adminUnlockRequest	false	"Client IP to unlock"
+//	@Success		200		{object}	string				"unlocked"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/admin/user/{id}/unlock [post]
+//
+//	@Security		BearerAuth
+func (app *application) adminUnlockUser(c *gin.Context) {
+
+	var payload adminUnlockRequest
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := app.store.Users.GetUserById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
+		return
+	}
+
+	if err := app.store.LoginThrottles.ClearLoginThrottle(c.Request.Context(), store.LoginThrottleAccount, loginThrottleSubject(user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock user"})
+		return
+	}
+
+	if err := app.store.LoginThrottles.ClearLoginThrottle(c.Request.Context(), store.LoginThrottleMFA, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock user"})
+		return
+	}
+
+	if payload.IP != "" {
+		if err := app.store.LoginThrottles.ClearLoginThrottle(c.Request.Context(), store.LoginThrottleIP, payload.IP); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock ip"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, "user unlocked successfully")
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/puremike/pcourierds/docs"
+	"github.com/puremike/pcourierds/internal/store"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -13,36 +14,114 @@ func (app *application) routes() http.Handler {
 
 	g := gin.Default()
 
+	// client IPs key the login throttle and rate limits, so X-Forwarded-For is only
+	// believed from configured proxies
+	if err := g.SetTrustedProxies(app.config.trustedProxies); err != nil {
+		app.logger.Fatalw("invalid TRUSTED_PROXIES", "error", err)
+	}
+
+	g.GET("/.well-known/jwks.json", app.getJWKS)
+
 	docs.SwaggerInfo.BasePath = "/api/v1"
 	api := g.Group("/api/v1")
 	{
 		api.GET("/health", app.basicAuthentication(), app.health)
 		api.GET("swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+		api.GET("/track/:code", app.rateLimitByIP(app.rateLimits.trackingIP), app.trackPackage)
+		api.GET("/documents/:id", app.downloadDocument)
 	}
 
 	users := api.Group("/auth")
 	{
 		users.POST("/signup", app.createUser)
 		users.POST("/login", app.login)
+		users.POST("/login/mfa", app.requireMFAConfigured(), app.loginMFA)
+		users.POST("/refresh", app.refreshToken)
+		users.POST("/forgot-password", app.rateLimitByIP(app.rateLimits.forgotPasswordIP), app.forgotPassword)
+		users.POST("/reset-password", app.rateLimitByIP(app.rateLimits.resetPasswordIP), app.resetPassword)
+		users.GET("/verify-email", app.verifyEmail)
+	}
+
+	// routes integrations can also call with an API key, limited by its scopes
+	integrationGroup := api.Group("/")
+	integrationGroup.Use(app.apiKeyOrAuthMiddleware())
+	{
+		integrationGroup.POST("/quotes", app.requireScope(store.APIKeyScopePackagesWrite), app.createQuote)
+		integrationGroup.POST("/packages", app.requireScope(store.APIKeyScopePackagesWrite), app.requireVerifiedEmail(), app.createPackage)
+		integrationGroup.GET("/packages", app.requireScope(store.APIKeyScopePackagesRead), app.getMyPackages)
+		integrationGroup.GET("/packages/:id", app.requireScope(store.APIKeyScopePackagesRead), app.getPackageMiddleware(), app.getPackageById)
+		integrationGroup.PATCH("/packages/:id/cancel", app.requireScope(store.APIKeyScopePackagesWrite), app.getPackageMiddleware(), app.cancelPackage)
+		integrationGroup.GET("/packages/:id/history", app.requireScope(store.APIKeyScopeTrackingRead), app.getPackageMiddleware(), app.getPackageHistory)
 	}
 
 	authGroup := api.Group("/")
 	authGroup.Use(app.authMiddleware())
 	{
 		authGroup.GET("/auth/me", app.userProfile)
+		authGroup.POST("/auth/logout", app.logout)
 		authGroup.PATCH("/auth/update-profile", app.updateProfile)
 		authGroup.PUT("/auth/change-password", app.updatePassword)
+		authGroup.POST("/auth/verify-email/resend", app.resendVerificationEmail)
+		authGroup.POST("/auth/mfa/enroll", app.requireMFAConfigured(), app.enrollMFA)
+		authGroup.POST("/auth/mfa/enroll/confirm", app.requireMFAConfigured(), app.confirmMFA)
+		authGroup.POST("/auth/mfa/recovery-codes", app.requireMFAConfigured(), app.regenerateRecoveryCodes)
+		authGroup.POST("/auth/mfa/disable", app.requireMFAConfigured(), app.disableMFA)
 
-		authGroup.POST("/dispatchers/apply", app.dispatcherApply)
+		authGroup.POST("/api-keys", app.requireVerifiedEmail(), app.requireAdminMFA(), app.createAPIKey)
+		authGroup.GET("/api-keys", app.getMyAPIKeys)
+		authGroup.DELETE("/api-keys/:id", app.revokeMyAPIKey)
+		authGroup.GET("/admin/user/:id/api-keys", app.authorizeRoles("admin"), app.adminGetUserAPIKeys)
+		authGroup.DELETE("/admin/api-keys/:id", app.authorizeRoles("admin"), app.adminRevokeAPIKey)
+
+		authGroup.PATCH("/packages/:id", app.getPackageMiddleware(), app.updatePackage)
+		authGroup.PATCH("/packages/:id/status", app.authorizeRoles("dispatcher", "admin"), app.getPackageMiddleware(), app.updatePackageStatus)
+		authGroup.POST("/packages/:id/deliver", app.authorizeRoles("dispatcher"), app.getPackageMiddleware(), app.confirmDelivery)
+		authGroup.GET("/packages/:id/proof", app.getPackageMiddleware(), app.getDeliveryProof)
+		authGroup.GET("/packages/:id/proof/:attachment", app.getPackageMiddleware(), app.getDeliveryProofAttachment)
+		authGroup.GET("/dispatchers/me/packages", app.authorizeRoles("dispatcher"), app.getDispatcherPackages)
+		authGroup.POST("/dispatchers/me/location", app.authorizeRoles("dispatcher"), app.recordLocation)
+		authGroup.GET("/dispatchers/me/offers", app.authorizeRoles("dispatcher"), app.getMyOffers)
+		authGroup.GET("/dispatchers/me/offers/stats", app.authorizeRoles("dispatcher"), app.getMyOfferStats)
+		authGroup.POST("/dispatchers/me/offers/:id/accept", app.authorizeRoles("dispatcher"), app.acceptOffer)
+		authGroup.POST("/dispatchers/me/offers/:id/decline", app.authorizeRoles("dispatcher"), app.declineOffer)
+		authGroup.GET("/admin/dispatchers/:id/offer-stats", app.authorizeRoles("admin"), app.getDispatcherOfferStats)
+		authGroup.GET("/admin/dispatchers/nearby", app.authorizeRoles("admin"), app.getNearbyDispatchers)
+
+		authGroup.POST("/dispatchers/apply", app.requireVerifiedEmail(), app.dispatcherApply)
+		authGroup.GET("/dispatchers/apply/me", app.getMyApplication)
+		authGroup.PATCH("/dispatchers/apply/me", app.updateMyApplication)
+		authGroup.DELETE("/dispatchers/apply/me", app.withdrawMyApplication)
+		authGroup.POST("/dispatchers/apply/me/documents", app.uploadApplicationDocument)
+		authGroup.GET("/dispatchers/apply/me/documents", app.getMyApplicationDocuments)
 		authGroup.GET("/admin/dispatcher-applications", app.authorizeRoles("admin"), app.getAllApplications)
+		authGroup.GET("/admin/dispatcher-applications/duplicates", app.authorizeRoles("admin"), app.getDuplicateIdentifiers)
 		authGroup.GET("/admin/dispatcher-applications/:id", app.authorizeRoles("admin"), app.getDispatcherAppMiddleware(), app.getDispatcherApplicationById)
+		authGroup.GET("/admin/dispatcher-applications/:id/history", app.authorizeRoles("admin"), app.getDispatcherAppMiddleware(), app.getApplicationHistory)
+		authGroup.GET("/admin/dispatcher-applications/:id/documents", app.authorizeRoles("admin"), app.getDispatcherAppMiddleware(), app.getApplicationDocuments)
 		authGroup.PATCH("/admin/approve-dispatcher/:userID", app.authorizeRoles("admin"), app.getDispatcherAppByUserIdMiddleware(), app.approveDenyApplication)
+		authGroup.GET("/admin/application-rules", app.authorizeRoles("admin"), app.getApplicationRules)
+		authGroup.PUT("/admin/application-rules/:region", app.authorizeRoles("admin"), app.upsertApplicationRule)
+		authGroup.DELETE("/admin/application-rules/:region", app.authorizeRoles("admin"), app.deleteApplicationRule)
 
 		authGroup.GET("/admin/user/:id", app.authorizeRoles("user", "admin"), app.getUserById)
 		authGroup.GET("/admin/users", app.authorizeRoles("admin"), app.getUsers)
 		authGroup.POST("/admin/user", app.authorizeRoles("admin"), app.adminCreateUser)
 		authGroup.PATCH("/admin/user/:id", app.authorizeRoles("admin"), app.adminUpdateProfile)
 		authGroup.DELETE("/admin/user/:id", app.authorizeRoles("admin"), app.adminDeleteUser)
+		authGroup.POST("/admin/user/:id/revoke-tokens", app.authorizeRoles("admin"), app.adminRevokeUserTokens)
+		authGroup.DELETE("/admin/user/:id/mfa", app.authorizeRoles("admin"), app.adminResetUserMFA)
+		authGroup.POST("/admin/user/:id/unlock", app.authorizeRoles("admin"), app.adminUnlockUser)
+
+		authGroup.POST("/admin/packages/:id/assign", app.authorizeRoles("admin"), app.assignPackage)
+
+		authGroup.GET("/admin/pricing/rates", app.authorizeRoles("admin"), app.getPricingRates)
+		authGroup.PUT("/admin/pricing/rates/:vehicleType", app.authorizeRoles("admin"), app.upsertPricingRate)
+		authGroup.PUT("/admin/pricing/rates/:vehicleType/tiers", app.authorizeRoles("admin"), app.replaceWeightTiers)
+
+		authGroup.GET("/vehicle-types", app.getVehicleTypes)
+		authGroup.GET("/admin/vehicle-types", app.authorizeRoles("admin"), app.getAllVehicleTypes)
+		authGroup.PUT("/admin/vehicle-types/:code", app.authorizeRoles("admin"), app.upsertVehicleType)
+		authGroup.DELETE("/admin/vehicle-types/:code", app.authorizeRoles("admin"), app.deleteVehicleType)
 	}
 
 	return g
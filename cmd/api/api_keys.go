@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/auth"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+
+	// apiKeyTouchInterval limits how often last_used_at is written for a busy key.
+	apiKeyTouchInterval = time.Minute
+)
+
+type createAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // never expires when omitted
+}
+
+type createAPIKeyResponse struct {
+	Key    string         `json:"key"` // only returned once
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// apiKeyMiddleware authenticates requests by the X-API-Key header. The key's owner is
+// set as the user, like authMiddleware does, along with the key's scopes for
+// requireScope. A key only ever acts for its owner as a sender: handlers must not grant
+// admin or dispatcher access to API key requests, see isAPIKeyRequest.
+func (app *application) apiKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := app.store.APIKeys.GetAPIKeyByHash(c.Request.Context(), auth.HashOpaqueToken(c.GetHeader(apiKeyHeader)))
+		if err != nil {
+			if errors.Is(err, store.ErrAPIKeyNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check api key"})
+			return
+		}
+
+		now := time.Now()
+
+		if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key has been revoked or has expired"})
+			return
+		}
+
+		user, err := app.store.Users.GetUserById(c.Request.Context(), key.UserID)
+		if err != nil {
+			if errors.Is(err, store.ErrUserNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
+			return
+		}
+
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+			if err := app.store.APIKeys.TouchAPIKey(c.Request.Context(), key.ID, now); err != nil {
+				app.logger.Errorw("failed to record api key use", "api_key_id", key.ID, "error", err)
+			}
+		}
+
+		c.Set("user", user)
+		c.Set("userId", user.ID)
+		c.Set("apiKeyId", key.ID)
+		c.Set("apiKeyScopes", key.Scopes)
+		c.Next()
+	}
+}
+
+// apiKeyOrAuthMiddleware authenticates by API key when the X-API-Key header is sent
+// and by access token otherwise. Routes using it must check scopes with requireScope.
+func (app *application) apiKeyOrAuthMiddleware() gin.HandlerFunc {
+	apiKey, token := app.apiKeyMiddleware(), app.authMiddleware()
+
+	return func(c *gin.Context) {
+		if c.GetHeader(apiKeyHeader) != "" {
+			apiKey(c)
+			return
+		}
+		token(c)
+	}
+}
+
+// isAPIKeyRequest reports whether the request was authenticated with an API key rather
+// than an access token.
+func isAPIKeyRequest(c *gin.Context) bool {
+	_, ok := c.Get("apiKeyId")
+	return ok
+}
+
+// requireScope rejects API key requests whose key was not granted scope. Requests
+// authenticated with an access token are not limited by scopes.
+func (app *application) requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAPIKeyRequest(c) {
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(c.GetStringSlice("apiKeyScopes"), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key is missing the " + scope + " scope"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create API key
+//	@Description	Create an API key that calls the API as the current user, limited to its scopes: packages:read, packages:write, tracking:read. Keys only reach the user's own packages, whatever their role. The key is only shown in this response
+//	@Tags			API Keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createAPIKeyRequest	true	"Key name, scopes and lifetime"
+//	@Success		201		{object}	createAPIKeyResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/api-keys [post]
+//
+//	@Security		BearerAuth
+func (app *application) createAPIKey(c *gin.Context) {
+	var payload createAPIKeyRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, scope := range payload.Scopes {
+		if !store.IsValidAPIKeyScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + scope})
+			return
+		}
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	value, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate api key"})
+		return
+	}
+
+	slices.Sort(payload.Scopes)
+
+	key := &models.APIKey{
+		UserID:  authUser.ID,
+		Name:    payload.Name,
+		Prefix:  prefix,
+		KeyHash: hash,
+		Scopes:  slices.Compact(payload.Scopes),
+	}
+
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, payload.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := app.store.APIKeys.CreateAPIKey(c.Request.Context(), key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, createAPIKeyResponse{Key: value, APIKey: key})
+}
+
+// GetMyAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the current user's API keys, including revoked and expired ones
+//	@Tags			API Keys
+//	@Produce		json
+//	@Success		200	{array}		models.APIKey
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/api-keys [get]
+//
+//	@Security		BearerAuth
+func (app *application) getMyAPIKeys(c *gin.Context) {
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	keys, err := app.store.APIKeys.GetUserAPIKeys(c.Request.Context(), authUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve api keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeMyAPIKey godoc
+//
+//	@Summary		Revoke API key
+//	@Description	Revoke one of the current user's API keys. Requests with it are rejected from now on
+//	@Tags			API Keys
+//	@Produce		json
+//	@Param			id	path		string	true	"API key ID"
+//	@Success		200	{object}	string	"api key revoked"
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/api-keys/{id} [delete]
+//
+//	@Security		BearerAuth
+func (app *application) revokeMyAPIKey(c *gin.Context) {
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	app.revokeAPIKey(c, authUser.ID)
+}
+
+// AdminRevokeAPIKey godoc
+//
+//	@Summary		Revoke any API key
+//	@Description	Revoke an API key of any user
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"API key ID"
+//	@Success		200	{object}	string	"api key revoked"
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/api-keys/{id} [delete]
+//
+//	@Security		BearerAuth
+func (app *application) adminRevokeAPIKey(c *gin.Context) {
+	app.revokeAPIKey(c, "")
+}
+
+func (app *application) revokeAPIKey(c *gin.Context, userId string) {
+	if err := app.store.APIKeys.RevokeAPIKey(c.Request.Context(), c.Param("id"), userId); err != nil {
+		if errors.Is(err, store.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "api key revoked successfully")
+}
+
+// GetUserAPIKeys godoc
+//
+//	@Summary		List a user's API keys
+//	@Description	List the API keys of any user, including revoked and expired ones
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{array}		models.APIKey
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/user/{id}/api-keys [get]
+//
+//	@Security		BearerAuth
+func (app *application) adminGetUserAPIKeys(c *gin.Context) {
+	keys, err := app.store.APIKeys.GetUserAPIKeys(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve api keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/blob"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+// documentTypes maps the accepted document content types to file extensions.
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+type applicationDocumentResponse struct {
+	ID            string `json:"id"`
+	ApplicationID string `json:"application_id"`
+	Kind          string `json:"kind"`
+	ContentType   string `json:"content_type"`
+	SizeBytes     int64  `json:"size_bytes"`
+	SHA256        string `json:"sha256"`
+	DownloadURL   string `json:"download_url,omitempty"`
+	URLExpiresAt  string `json:"url_expires_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+func newApplicationDocumentResponse(doc *models.ApplicationDocument) applicationDocumentResponse {
+	return applicationDocumentResponse{
+		ID:            doc.ID,
+		ApplicationID: doc.ApplicationID,
+		Kind:          doc.Kind,
+		ContentType:   doc.ContentType,
+		SizeBytes:     doc.SizeBytes,
+		SHA256:        doc.SHA256,
+		CreatedAt:     doc.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     doc.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// documentDownloadURL returns a signed link to the document that works without an
+// Authorization header until it expires.
+func (app *application) documentDownloadURL(doc *models.ApplicationDocument, now time.Time) (string, time.Time) {
+	expires := now.Add(app.config.documentConfig.urlTTL)
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", app.documentURLs.Sign(doc.ID, expires))
+
+	return "/api/v1/documents/" + doc.ID + "?" + query.Encode(), expires
+}
+
+// UploadApplicationDocument godoc
+//
+//	@Summary		Upload an application document
+//	@Description	Attach a document to the current user's pending dispatcher application. Uploading a kind again replaces the earlier file
+//	@Tags			DispatchersApply
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			kind	formData	string	true	"driver_license, vehicle_registration, insurance_certificate or selfie"
+//	@Param			file	formData	file	true	"PDF, JPEG or PNG file"
+//	@Success		201		{object}	applicationDocumentResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		413		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/dispatchers/apply/me/documents [post]
+//
+//	@Security		BearerAuth
+func (app *application) uploadApplicationDocument(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcherApp, err := app.store.DispatcherApplications.GetApplicationByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher application"})
+		return
+	}
+
+	if dispatcherApp.Status != store.ApplicationStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "documents can only be added to a pending application"})
+		return
+	}
+
+	maxBytes := app.config.documentConfig.maxBytes
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+(1<<20))
+	if err := c.Request.ParseMultipartForm(1 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form"})
+		return
+	}
+
+	kind := c.PostForm("kind")
+	if !store.IsValidDocumentKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document kind", "kinds": store.ApplicationDocumentKinds})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d MB", maxBytes>>20)})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file"})
+		return
+	}
+	defer file.Close()
+
+	contentType, content, err := sniffContentType(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file"})
+		return
+	}
+
+	ext, ok := documentTypes[contentType]
+	if !ok || (kind == "selfie" && contentType == "application/pdf") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file must be a PDF, JPEG or PNG; selfies must be an image"})
+		return
+	}
+
+	suffix, err := randomKeySuffix()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store document"})
+		return
+	}
+
+	key := "applications/" + dispatcherApp.ID + "/" + kind + "-" + suffix + ext
+	hash := sha256.New()
+
+	if err := app.blobs.Put(c.Request.Context(), key, io.TeeReader(content, hash)); err != nil {
+		app.logger.Errorw("failed to store application document", "application_id", dispatcherApp.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store document"})
+		return
+	}
+
+	doc := &models.ApplicationDocument{
+		ApplicationID: dispatcherApp.ID,
+		Kind:          kind,
+		BlobKey:       key,
+		ContentType:   contentType,
+		SizeBytes:     header.Size,
+		SHA256:        hex.EncodeToString(hash.Sum(nil)),
+		UploadedBy:    authUser.ID,
+	}
+
+	replacedKey, err := app.store.DispatcherApplications.SaveDocument(c.Request.Context(), doc)
+	if err != nil {
+		if err := app.blobs.Delete(c.Request.Context(), key); err != nil {
+			app.logger.Errorw("failed to delete application document", "key", key, "error", err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save document"})
+		return
+	}
+
+	if replacedKey != "" {
+		if err := app.blobs.Delete(c.Request.Context(), replacedKey); err != nil {
+			app.logger.Errorw("failed to delete replaced application document", "key", replacedKey, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, newApplicationDocumentResponse(doc))
+}
+
+// GetMyApplicationDocuments godoc
+//
+//	@Summary		Get my application documents
+//	@Description	List the documents attached to the current user's latest dispatcher application
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		applicationDocumentResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/dispatchers/apply/me/documents [get]
+//
+//	@Security		BearerAuth
+func (app *application) getMyApplicationDocuments(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcherApp, err := app.store.DispatcherApplications.GetApplicationByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher application"})
+		return
+	}
+
+	docs, err := app.store.DispatcherApplications.GetDocumentsByApplicationId(c.Request.Context(), dispatcherApp.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve documents"})
+		return
+	}
+
+	response := []applicationDocumentResponse{}
+	for _, doc := range *docs {
+		response = append(response, newApplicationDocumentResponse(&doc))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetApplicationDocuments godoc
+//
+//	@Summary		Get application documents
+//	@Description	List the documents of a dispatcher application with short-lived download links
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Dispatcher Application ID"
+//	@Success		200	{array}		applicationDocumentResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/dispatcher-applications/{id}/documents [get]
+//
+//	@Security		BearerAuth
+func (app *application) getApplicationDocuments(c *gin.Context) {
+
+	dispatcherApp, err := app.getDispatcherAppFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+		return
+	}
+
+	docs, err := app.store.DispatcherApplications.GetDocumentsByApplicationId(c.Request.Context(), dispatcherApp.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve documents"})
+		return
+	}
+
+	now := time.Now()
+	response := []applicationDocumentResponse{}
+	for _, doc := range *docs {
+		res := newApplicationDocumentResponse(&doc)
+		downloadURL, expires := app.documentDownloadURL(&doc, now)
+		res.DownloadURL = downloadURL
+		res.URLExpiresAt = expires.Format(time.RFC3339)
+		response = append(response, res)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadDocument godoc
+//
+//	@Summary		Download an application document
+//	@Description	Download a document through a signed link from GET /admin/dispatcher-applications/{id}/documents. No Authorization header is needed
+//	@Tags			DispatchersApply
+//	@Produce		application/pdf,image/jpeg,image/png
+//	@Param			id			path		string	true	"Document ID"
+//	@Param			expires		query		string	true	"Link expiry (Unix time)"
+//	@Param			signature	query		string	true	"Link signature"
+//	@Success		200			{file}		file
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/documents/{id} [get]
+func (app *application) downloadDocument(c *gin.Context) {
+
+	id := c.Param("id")
+
+	if err := app.documentURLs.Verify(id, c.Query("expires"), c.Query("signature"), time.Now()); err != nil {
+		if errors.Is(err, blob.ErrLinkExpired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "download link has expired"})
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid download link"})
+		return
+	}
+
+	doc, err := app.store.DispatcherApplications.GetDocumentById(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve document"})
+		return
+	}
+
+	r, err := app.blobs.Open(c.Request.Context(), doc.BlobKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve document"})
+		return
+	}
+	defer r.Close()
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("ETag", `"`+doc.SHA256+`"`)
+	c.DataFromReader(http.StatusOK, doc.SizeBytes, doc.ContentType, r, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s%s"`, doc.Kind, documentTypes[doc.ContentType]),
+	})
+}
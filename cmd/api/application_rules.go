@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/eligibility"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+type applicationRuleRequest struct {
+	PlatePattern       string   `json:"plate_pattern"`
+	LicensePattern     string   `json:"license_pattern"`
+	MinVehicleYear     int      `json:"min_vehicle_year" binding:"gte=0"`
+	MaxVehicleAgeYears int      `json:"max_vehicle_age_years" binding:"gte=0"`
+	AllowedMakes       []string `json:"allowed_makes" binding:"dive,required"`
+}
+
+// GetApplicationRules godoc
+//
+//	@Summary		Get application rules
+//	@Description	Get the dispatcher application requirements of every region
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.ApplicationRule
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/application-rules [get]
+//
+//	@Security		BearerAuth
+func (app *application) getApplicationRules(c *gin.Context) {
+
+	rules, err := app.store.ApplicationRules.GetAllRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve application rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// UpsertApplicationRule godoc
+//
+//	@Summary		Create or update an application rule
+//	@Description	Set the dispatcher application requirements of a region. Patterns are regular expressions that must match the whole value; empty or zero requirements are not enforced
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			region	path		string					true	"Region"
+//	@Param			payload	body		applicationRuleRequest	true	"Rule payload"
+//	@Success		200		{object}	models.ApplicationRule
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/admin/application-rules/{region} [put]
+//
+//	@Security		BearerAuth
+func (app *application) upsertApplicationRule(c *gin.Context) {
+
+	var payload applicationRuleRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields := eligibility.FieldErrors{}
+	if _, err := eligibility.CompilePattern(payload.PlatePattern); err != nil {
+		fields["plate_pattern"] = err.Error()
+	}
+	if _, err := eligibility.CompilePattern(payload.LicensePattern); err != nil {
+		fields["license_pattern"] = err.Error()
+	}
+	if len(fields) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pattern", "fields": fields})
+		return
+	}
+
+	rule := &models.ApplicationRule{
+		Region:             strings.ToLower(c.Param("region")),
+		PlatePattern:       payload.PlatePattern,
+		LicensePattern:     payload.LicensePattern,
+		MinVehicleYear:     payload.MinVehicleYear,
+		MaxVehicleAgeYears: payload.MaxVehicleAgeYears,
+		AllowedMakes:       payload.AllowedMakes,
+	}
+
+	updatedRule, err := app.store.ApplicationRules.UpsertRule(c.Request.Context(), rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save application rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedRule)
+}
+
+// DeleteApplicationRule godoc
+//
+//	@Summary		Delete an application rule
+//	@Description	Stop accepting dispatcher applications from a region
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			region	path		string	true	"Region"
+//	@Success		200		{object}	string
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/admin/application-rules/{region} [delete]
+//
+//	@Security		BearerAuth
+func (app *application) deleteApplicationRule(c *gin.Context) {
+
+	if err := app.store.ApplicationRules.DeleteRule(c.Request.Context(), strings.ToLower(c.Param("region"))); err != nil {
+		if errors.Is(err, store.ErrApplicationRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application rule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete application rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "application rule deleted successfully")
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/puremike/pcourierds/internal/auth"
 	"github.com/puremike/pcourierds/internal/models"
 	"github.com/puremike/pcourierds/internal/store"
 	"golang.org/x/crypto/bcrypt"
@@ -20,11 +25,12 @@ type createUserRequest struct {
 }
 
 type userResponse struct {
-	ID        string `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	CreatedAt string `json:"created_at"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	CreatedAt     string `json:"created_at"`
 }
 
 type loginRequest struct {
@@ -33,9 +39,18 @@ type loginRequest struct {
 }
 
 type loginResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Token    string `json:"token"`
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"` // read from the refresh_token cookie when empty
 }
 
 type userProfileUpdateRequest struct {
@@ -93,26 +108,31 @@ func (app *application) createUser(c *gin.Context) {
 		return
 	}
 
+	go app.sendEmailVerification(createdUser)
+
 	c.JSON(http.StatusCreated, userResponse{
-		ID:        createdUser.ID,
-		Username:  createdUser.Username,
-		Email:     createdUser.Email,
-		Role:      createdUser.Role,
-		CreatedAt: createdUser.CreatedAt.Format(time.RFC3339),
+		ID:            createdUser.ID,
+		Username:      createdUser.Username,
+		Email:         createdUser.Email,
+		Role:          createdUser.Role,
+		EmailVerified: createdUser.EmailVerifiedAt != nil,
+		CreatedAt:     createdUser.CreatedAt.Format(time.RFC3339),
 	})
 }
 
 // loginUser handles user login and returns a JWT token if credentials are valid.
 //
 //	@Summary		Login User
-//	@Description	Authenticates a user using email and password, and returns a JWT token on success.
+//	@Description	Authenticates a user using email and password, and returns a JWT access token and a refresh token on success. Users with two-factor authentication get an mfa_token to complete the login at /auth/login/mfa instead.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		loginRequest	true	"Login credentials"
 //	@Success		200		{object}	loginResponse
+//	@Success		200		{object}	mfaChallengeResponse
 //	@Failure		400		{object}	gin.H	"Bad Request - invalid input"
 //	@Failure		401		{object}	gin.H	"Unauthorized - invalid credentials"
+//	@Failure		429		{object}	gin.H	"Too Many Requests - locked after repeated failures"
 //	@Failure		500		{object}	gin.H	"Internal Server Error"
 //	@Router			/auth/login [post]
 func (app *application) login(c *gin.Context) {
@@ -123,42 +143,264 @@ func (app *application) login(c *gin.Context) {
 		return
 	}
 
+	retryAfter, err := app.loginRetryAfter(c.Request.Context(), payload.Email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login attempts"})
+		return
+	}
+
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+		return
+	}
+
 	user, err := app.store.Users.GetUserByEmail(c.Request.Context(), payload.Email)
+	if err != nil && !errors.Is(err, store.ErrUserNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
+		return
+	}
+
+	// unknown emails get the same response, after the same bcrypt work, as a wrong password
+	passwordHash := dummyPasswordHash
+	if user != nil {
+		passwordHash = []byte(user.Password)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(payload.Password)); err != nil || user == nil {
+		app.recordLoginFailure(c.Request.Context(), payload.Email, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+
+	if err := app.store.LoginThrottles.ClearLoginThrottle(c.Request.Context(), store.LoginThrottleAccount, loginThrottleSubject(payload.Email)); err != nil {
+		app.logger.Errorw("failed to clear login failures", "user_id", user.ID, "error", err)
+	}
+
+	mfa, err := app.store.MFA.GetMFA(c.Request.Context(), user.ID)
+	if err != nil && !errors.Is(err, store.ErrMFANotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve two-factor settings"})
+		return
+	}
+
+	if mfa != nil && mfa.EnabledAt != nil {
+		challenge, err := app.issueMFAChallenge(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge,
+			ExpiresIn:   int(app.config.mfaConfig.challengeTTL.Seconds()),
+		})
+		return
+	}
+
+	token, err := app.issueAccessToken(user, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	refreshToken, err := app.issueRefreshToken(c.Request.Context(), &models.RefreshToken{UserID: user.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	if err := app.setSessionCookies(c, token, refreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate csrf token"})
+		return
+	}
+
+	res := loginResponse{ID: user.ID, Username: user.Username, Token: token, RefreshToken: refreshToken}
+	c.JSON(http.StatusOK, res)
+}
+
+// RefreshToken godoc
+//
+//	@Summary		Refresh access token
+//	@Description	Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once; using one twice revokes every token issued from the same login. Browsers may omit the body to use the refresh_token cookie, sending the csrf_token cookie in the X-CSRF-Token header
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		refreshTokenRequest	false	"Refresh token"
+//	@Success		200		{object}	loginResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/refresh [post]
+func (app *application) refreshToken(c *gin.Context) {
+	var payload refreshTokenRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	presented := payload.RefreshToken
+	if presented == "" {
+		if cookie, err := c.Cookie(refreshTokenCookie); err == nil && cookie != "" {
+			if !validCSRF(c) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "missing or invalid csrf token"})
+				return
+			}
+			presented = cookie
+		}
+	}
+
+	if presented == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is required"})
+		return
+	}
+
+	refreshToken, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	next := &models.RefreshToken{TokenHash: hash, ExpiresAt: time.Now().Add(app.config.authConfig.refreshTokenExp)}
+
+	if err := app.store.RefreshTokens.RotateRefreshToken(c.Request.Context(), auth.HashOpaqueToken(presented), next); err != nil {
+		switch {
+		case errors.Is(err, store.ErrRefreshTokenReused):
+			app.logger.Warnw("refresh token reused, session revoked", "ip", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has already been used, please log in again"})
+		case errors.Is(err, store.ErrRefreshTokenInvalid), errors.Is(err, store.ErrRefreshTokenExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		}
+		return
+	}
+
+	user, err := app.store.Users.GetUserById(c.Request.Context(), next.UserID)
 	if err != nil {
 		if errors.Is(err, store.ErrUserNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "invalid email or password"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+	accessToken, err := app.issueAccessToken(user, next.MFAVerified)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	if err := app.setSessionCookies(c, accessToken, refreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate csrf token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse{ID: user.ID, Username: user.Username, Token: accessToken, RefreshToken: refreshToken})
+}
+
+// Logout godoc
+//
+//	@Summary		Logout
+//	@Description	Revoke the access token used for this request and, when given or sent as a cookie, the refresh token issued with it. Session cookies are cleared
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		logoutRequest	false	"Refresh token to revoke"
+//	@Success		200		{object}	string			"logged out"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/logout [post]
+//
+//	@Security		BearerAuth
+func (app *application) logout(c *gin.Context) {
+	var payload logoutRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	jti, expiresAt := c.GetString("tokenId"), c.GetTime("tokenExpiresAt")
+
+	if err := app.store.RevokedTokens.RevokeToken(c.Request.Context(), jti, authUser.ID, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
 		return
 	}
+	app.revokedTokens.Add(jti, expiresAt)
+
+	if payload.RefreshToken == "" {
+		payload.RefreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+
+	if payload.RefreshToken != "" {
+		if err := app.store.RefreshTokens.RevokeRefreshTokenFamily(c.Request.Context(), auth.HashOpaqueToken(payload.RefreshToken), authUser.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke refresh token"})
+			return
+		}
+	}
+
+	app.clearSessionCookies(c)
+
+	c.JSON(http.StatusOK, "logged out successfully")
+}
+
+// issueAccessToken signs a short-lived access token for user. The amr claim records
+// whether the login completed two-factor authentication.
+func (app *application) issueAccessToken(user *models.User, mfaVerified bool) (string, error) {
+	now := time.Now()
+
+	jti, err := auth.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	amr := []string{"pwd"}
+	if mfaVerified {
+		amr = append(amr, "otp")
+	}
 
 	claims := jwt.MapClaims{
+		"jti":  jti,
 		"sub":  user.ID,
 		"role": user.Role,
+		"amr":  amr,
 		"iss":  app.config.authConfig.iss,
 		"aud":  app.config.authConfig.aud,
-		"iat":  time.Now().Unix(),
-		"nbf":  time.Now().Unix(),
-		"exp":  time.Now().Add(app.config.authConfig.tokenExp).Unix(),
+		"iat":  now.Unix(),
+		"nbf":  now.Unix(),
+		"exp":  now.Add(app.config.authConfig.tokenExp).Unix(),
 	}
 
-	token, err := app.jwtAuth.GenerateToken(claims)
+	return app.jwtAuth.GenerateToken(claims)
+}
+
+// issueRefreshToken stores a new refresh token for token.UserID, in token.FamilyID if
+// set, and returns the opaque value to hand to the client.
+func (app *application) issueRefreshToken(ctx context.Context, token *models.RefreshToken) (string, error) {
+	value, hash, err := auth.NewOpaqueToken()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
-		return
+		return "", err
 	}
 
-	c.SetCookie("jwt", token, int(app.config.authConfig.tokenExp.Seconds()), "/", "localhost", false, true)
-	c.SetSameSite(http.SameSiteLaxMode)
+	token.TokenHash = hash
+	token.ExpiresAt = time.Now().Add(app.config.authConfig.refreshTokenExp)
 
-	res := loginResponse{ID: user.ID, Username: user.Username}
-	c.JSON(http.StatusOK, res)
+	if err := app.store.RefreshTokens.CreateRefreshToken(ctx, token); err != nil {
+		return "", err
+	}
+
+	return value, nil
 }
 
 // GetLoggedUserProfile godoc
@@ -183,11 +425,12 @@ func (app *application) userProfile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, userResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -242,12 +485,17 @@ func (app *application) updateProfile(c *gin.Context) {
 		return
 	}
 
+	if updatedUser.Email != authUser.Email {
+		go app.sendEmailVerification(updatedUser)
+	}
+
 	c.JSON(http.StatusCreated, userResponse{
-		ID:        updatedUser.ID,
-		Username:  updatedUser.Username,
-		Email:     updatedUser.Email,
-		Role:      updatedUser.Role,
-		CreatedAt: updatedUser.CreatedAt.Format(time.RFC3339),
+		ID:            updatedUser.ID,
+		Username:      updatedUser.Username,
+		Email:         updatedUser.Email,
+		Role:          updatedUser.Role,
+		EmailVerified: updatedUser.EmailVerifiedAt != nil,
+		CreatedAt:     updatedUser.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -51,3 +51,18 @@ func (app *application) getDispatcherAppByUserIdFromContext(c *gin.Context) (*mo
 
 	return dispatcherApp, nil
 }
+
+func (app *application) getPackageFromContext(c *gin.Context) (*models.Package, error) {
+
+	packageContext, exists := c.Get("package")
+	if !exists {
+		return nil, errors.New("package not found in context")
+	}
+
+	pack, ok := packageContext.(*models.Package)
+	if !ok {
+		return nil, errors.New("package context is not of type *models.Package")
+	}
+
+	return pack, nil
+}
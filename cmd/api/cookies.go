@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Browser sessions keep the access and refresh tokens in HttpOnly cookies. Requests
+// authenticated by cookie with an unsafe method must echo the csrf_token cookie in the
+// X-CSRF-Token header (double-submit), which other sites cannot read.
+const (
+	accessTokenCookie  = "jwt"
+	refreshTokenCookie = "refresh_token"
+	csrfCookie         = "csrf_token"
+	csrfHeader         = "X-CSRF-Token"
+
+	refreshTokenCookiePath = "/api/v1/auth" // only sent to the refresh and logout endpoints
+)
+
+// parseSameSite maps a COOKIE_SAMESITE value to its http.SameSite mode, defaulting to
+// Lax.
+func parseSameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func (app *application) setCookie(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   app.config.cookieConfig.domain,
+		MaxAge:   maxAge,
+		Secure:   app.config.cookieConfig.secure,
+		HttpOnly: httpOnly,
+		SameSite: app.config.cookieConfig.sameSite,
+	})
+}
+
+// setSessionCookies stores a login's tokens in cookies along with a new CSRF token.
+func (app *application) setSessionCookies(c *gin.Context, accessToken, refreshToken string) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+
+	refreshMaxAge := int(app.config.authConfig.refreshTokenExp.Seconds())
+
+	app.setCookie(c, accessTokenCookie, accessToken, "/", int(app.config.authConfig.tokenExp.Seconds()), true)
+	app.setCookie(c, refreshTokenCookie, refreshToken, refreshTokenCookiePath, refreshMaxAge, true)
+	// readable by the dashboard's scripts so they can send it back in csrfHeader
+	app.setCookie(c, csrfCookie, base64.RawURLEncoding.EncodeToString(raw), "/", refreshMaxAge, false)
+
+	return nil
+}
+
+func (app *application) clearSessionCookies(c *gin.Context) {
+	app.setCookie(c, accessTokenCookie, "", "/", -1, true)
+	app.setCookie(c, refreshTokenCookie, "", refreshTokenCookiePath, -1, true)
+	app.setCookie(c, csrfCookie, "", "/", -1, false)
+}
+
+// validCSRF reports whether the request's CSRF header matches its CSRF cookie.
+func validCSRF(c *gin.Context) bool {
+	cookie, err := c.Cookie(csrfCookie)
+	if err != nil || cookie == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(c.GetHeader(csrfHeader))) == 1
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   bool
+	}{
+		{"matching", "token", "token", true},
+		{"no cookie", "", "token", false},
+		{"no header", "token", "", false},
+		{"neither", "", "", false},
+		{"mismatch", "token", "other", false},
+		{"prefix of cookie", "token", "tok", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/packages", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(csrfHeader, tt.header)
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			if got := validCSRF(c); got != tt.want {
+				t.Errorf("validCSRF() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSafeMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{http.MethodGet, true},
+		{http.MethodHead, true},
+		{http.MethodOptions, true},
+		{http.MethodPost, false},
+		{http.MethodPut, false},
+		{http.MethodPatch, false},
+		{http.MethodDelete, false},
+	}
+
+	for _, tt := range tests {
+		if got := isSafeMethod(tt.method); got != tt.want {
+			t.Errorf("isSafeMethod(%s) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
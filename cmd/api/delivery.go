@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/blob"
+	"github.com/puremike/pcourierds/internal/mailer"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxDeliveryImageBytes caps each signature and photo upload.
+const maxDeliveryImageBytes = 5 << 20
+
+var errInvalidAttachment = errors.New("invalid attachment")
+
+// deliveryImageTypes maps the accepted attachment content types to file extensions.
+var deliveryImageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+type deliveryProofResponse struct {
+	ID           string `json:"id"`
+	PackageID    string `json:"package_id"`
+	Method       string `json:"method"`
+	SignatureURL string `json:"signature_url,omitempty"`
+	PhotoURL     string `json:"photo_url,omitempty"`
+	DeliveredBy  string `json:"delivered_by,omitempty"`
+	Location     string `json:"location"`
+	Note         string `json:"note"`
+	CreatedAt    string `json:"created_at"`
+}
+
+func newDeliveryProofResponse(proof *models.DeliveryProof) deliveryProofResponse {
+	response := deliveryProofResponse{
+		ID:          proof.ID,
+		PackageID:   proof.PackageID,
+		Method:      proof.Method,
+		DeliveredBy: proof.DeliveredBy,
+		Location:    proof.Location,
+		Note:        proof.Note,
+		CreatedAt:   proof.CreatedAt.Format(time.RFC3339),
+	}
+
+	if proof.SignatureKey != "" {
+		response.SignatureURL = "/api/v1/packages/" + proof.PackageID + "/proof/signature"
+	}
+	if proof.PhotoKey != "" {
+		response.PhotoURL = "/api/v1/packages/" + proof.PackageID + "/proof/photo"
+	}
+
+	return response
+}
+
+// generateDeliveryPin returns a random six digit PIN.
+func generateDeliveryPin() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// sendDeliveryPin emails the recipient the PIN they give the dispatcher on delivery.
+func (app *application) sendDeliveryPin(pack *models.Package, pin string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	msg := mailer.Message{
+		To:      pack.RecipientEmail,
+		Subject: "Your delivery PIN for " + pack.TrackingCode,
+		Body: fmt.Sprintf("Hi %s,\n\nA package is on its way to you from %s. Give the dispatcher this PIN when it arrives:\n\n%s\n\nYou can follow the delivery with tracking code %s. Do not share the PIN with anyone else.\n",
+			pack.RecipientName, pack.Origin, pin, pack.TrackingCode),
+	}
+
+	if err := app.mailer.Send(ctx, msg); err != nil {
+		app.logger.Errorw("failed to send delivery PIN email", "package_id", pack.ID, "error", err)
+	}
+}
+
+// storeDeliveryImage sniffs the uploaded file, rejects anything that is not an accepted
+// image and saves it to blob storage. It returns the blob key and content type.
+func (app *application) storeDeliveryImage(ctx context.Context, packageId, name string, header *multipart.FileHeader) (string, string, error) {
+	if header.Size > maxDeliveryImageBytes {
+		return "", "", fmt.Errorf("%w: %s must be at most %d MB", errInvalidAttachment, name, maxDeliveryImageBytes>>20)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	contentType, content, err := sniffContentType(file)
+	if err != nil {
+		return "", "", err
+	}
+
+	ext, ok := deliveryImageTypes[contentType]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s must be a PNG, JPEG or WebP image", errInvalidAttachment, name)
+	}
+
+	suffix, err := randomKeySuffix()
+	if err != nil {
+		return "", "", err
+	}
+
+	key := "deliveries/" + packageId + "/" + name + "-" + suffix + ext
+
+	if err := app.blobs.Put(ctx, key, content); err != nil {
+		return "", "", err
+	}
+
+	return key, contentType, nil
+}
+
+// ConfirmDelivery godoc
+//
+//	@Summary		Confirm delivery
+//	@Description	Mark a package out for delivery as delivered. Submit the recipient's PIN, or a signature image and a photo of the handover
+//	@Tags			Packages
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id			path		string	true	"Package ID"
+//	@Param			pin			formData	string	false	"Recipient's delivery PIN"
+//	@Param			signature	formData	file	false	"Recipient's signature (PNG, JPEG or WebP)"
+//	@Param			photo		formData	file	false	"Photo of the handover (PNG, JPEG or WebP)"
+//	@Param			location	formData	string	false	"Delivery location"
+//	@Param			note		formData	string	false	"Note"
+//	@Success		200			{object}	packageResponse
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		413			{object}	error
+//	@Failure		422			{object}	error
+//	@Failure		423			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/packages/{id}/deliver [post]
+//
+//	@Security		BearerAuth
+func (app *application) confirmDelivery(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	allowed, err := app.isAssignedDispatcher(c, authUser, pack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check package access"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	// fail before accepting any upload if the package cannot be delivered now
+	if !store.CanTransitionPackage(pack.Status, store.PackageStatusDelivered) {
+		c.JSON(http.StatusConflict, gin.H{"error": (&store.InvalidTransitionError{From: pack.Status, To: store.PackageStatusDelivered}).Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 2*maxDeliveryImageBytes+(1<<20))
+	if err := c.Request.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form"})
+		return
+	}
+
+	proof := &models.DeliveryProof{
+		PackageID:   pack.ID,
+		DeliveredBy: authUser.ID,
+		Location:    c.PostForm("location"),
+		Note:        c.PostForm("note"),
+	}
+
+	if pin := c.PostForm("pin"); pin != "" {
+		maxAttempts := app.config.deliveryConfig.maxPinAttempts
+		pinHash, attempts, err := app.store.Packages.ReserveDeliveryPinAttempt(c.Request.Context(), pack.ID, maxAttempts)
+		if err != nil {
+			if errors.Is(err, store.ErrDeliveryPinNotSet) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "package has no delivery pin, submit a signature and photo instead"})
+				return
+			}
+			if errors.Is(err, store.ErrDeliveryPinLocked) {
+				c.JSON(http.StatusLocked, gin.H{"error": "too many wrong pins, submit a signature and photo instead"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify delivery pin"})
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(pinHash), []byte(pin)); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid delivery pin", "attempts_remaining": max(maxAttempts-attempts, 0)})
+			return
+		}
+
+		if err := app.store.Packages.ResetDeliveryPinAttempts(c.Request.Context(), pack.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify delivery pin"})
+			return
+		}
+
+		proof.Method = store.DeliveryMethodPin
+	} else {
+		signature, sigErr := c.FormFile("signature")
+		photo, photoErr := c.FormFile("photo")
+		if sigErr != nil || photoErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a delivery pin, or a signature and a photo, is required"})
+			return
+		}
+
+		proof.Method = store.DeliveryMethodSignaturePhoto
+
+		proof.SignatureKey, proof.SignatureContentType, err = app.storeDeliveryImage(c.Request.Context(), pack.ID, "signature", signature)
+		if err != nil {
+			app.deliveryUploadError(c, err)
+			return
+		}
+
+		proof.PhotoKey, proof.PhotoContentType, err = app.storeDeliveryImage(c.Request.Context(), pack.ID, "photo", photo)
+		if err != nil {
+			app.deleteDeliveryImages(c.Request.Context(), proof)
+			app.deliveryUploadError(c, err)
+			return
+		}
+	}
+
+	event := &models.PackageStatusEvent{
+		ActorID:  authUser.ID,
+		Location: proof.Location,
+		Note:     proof.Note,
+	}
+
+	deliveredPackage, err := app.store.Packages.ConfirmDelivery(c.Request.Context(), proof, event)
+	if err != nil {
+		app.deleteDeliveryImages(c.Request.Context(), proof)
+
+		var transitionErr *store.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": transitionErr.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to confirm delivery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPackageResponse(deliveredPackage))
+}
+
+func (app *application) deliveryUploadError(c *gin.Context, err error) {
+	if errors.Is(err, errInvalidAttachment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	app.logger.Errorw("failed to store delivery attachment", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store attachment"})
+}
+
+// deleteDeliveryImages removes attachments of a proof that was never saved.
+func (app *application) deleteDeliveryImages(ctx context.Context, proof *models.DeliveryProof) {
+	for _, key := range []string{proof.SignatureKey, proof.PhotoKey} {
+		if key == "" {
+			continue
+		}
+		if err := app.blobs.Delete(ctx, key); err != nil {
+			app.logger.Errorw("failed to delete delivery attachment", "key", key, "error", err)
+		}
+	}
+}
+
+// GetDeliveryProof godoc
+//
+//	@Summary		Get proof of delivery
+//	@Description	Get the proof captured when a package was delivered. Available to the sender, the assigned dispatcher and admins
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Package ID"
+//	@Success		200	{object}	deliveryProofResponse
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/packages/{id}/proof [get]
+//
+//	@Security		BearerAuth
+func (app *application) getDeliveryProof(c *gin.Context) {
+
+	proof, ok := app.accessibleDeliveryProof(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newDeliveryProofResponse(proof))
+}
+
+// GetDeliveryProofAttachment godoc
+//
+//	@Summary		Download proof of delivery attachment
+//	@Description	Download the signature or photo captured when a package was delivered
+//	@Tags			Packages
+//	@Produce		image/png,image/jpeg,image/webp
+//	@Param			id			path		string	true	"Package ID"
+//	@Param			attachment	path		string	true	"signature or photo"
+//	@Success		200			{file}		file
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/packages/{id}/proof/{attachment} [get]
+//
+//	@Security		BearerAuth
+func (app *application) getDeliveryProofAttachment(c *gin.Context) {
+
+	proof, ok := app.accessibleDeliveryProof(c)
+	if !ok {
+		return
+	}
+
+	var key, contentType string
+	switch c.Param("attachment") {
+	case "signature":
+		key, contentType = proof.SignatureKey, proof.SignatureContentType
+	case "photo":
+		key, contentType = proof.PhotoKey, proof.PhotoContentType
+	}
+
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+
+	r, err := app.blobs.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve attachment"})
+		return
+	}
+	defer r.Close()
+
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, -1, contentType, r, nil)
+}
+
+// accessibleDeliveryProof loads the proof of the package in context, writing the error
+// response itself if the user may not see it or there is none.
+func (app *application) accessibleDeliveryProof(c *gin.Context) (*models.DeliveryProof, bool) {
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return nil, false
+	}
+
+	allowed, err := app.canAccessPackage(c, authUser, pack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check package access"})
+		return nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+
+	proof, err := app.store.Packages.GetDeliveryProof(c.Request.Context(), pack.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDeliveryProofNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery proof not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve delivery proof"})
+		return nil, false
+	}
+
+	return proof, true
+}
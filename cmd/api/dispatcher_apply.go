@@ -2,20 +2,26 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/eligibility"
 	"github.com/puremike/pcourierds/internal/models"
 	"github.com/puremike/pcourierds/internal/store"
 )
 
 type dispatcherApplyRequest struct {
-	VehicleType        string `json:"vehicle_type" binding:"required,oneof=car motorcycle"`
+	VehicleType        string `json:"vehicle_type" binding:"required"`
 	VehiclePlateNumber string `json:"vehicle_plate_number" binding:"required"`
 	VehicleYear        int    `json:"vehicle_year" binding:"required"`
 	VehicleModel       string `json:"vehicle_model" binding:"required"`
 	DriverLicense      string `json:"driver_license" binding:"required"`
+	Region             string `json:"region" binding:"required"`
+	VehicleMake        string `json:"vehicle_make" binding:"required"`
 }
 
 type dispatcherAppResponse struct {
@@ -26,10 +32,112 @@ type dispatcherAppResponse struct {
 	VehicleYear        int    `json:"vehicle_year"`
 	VehicleModel       string `json:"vehicle_model"`
 	DriverLicense      string `json:"driver_license"`
+	Region             string `json:"region"`
+	VehicleMake        string `json:"vehicle_make"`
 	Status             string `json:"status"` // pending, approved, rejected
+	ReviewedBy         string `json:"reviewed_by,omitempty"`
+	ReviewedAt         string `json:"reviewed_at,omitempty"`
+	RejectionReason    string `json:"rejection_reason,omitempty"`
+	ReviewNote         string `json:"review_note,omitempty"`
+	ReapplyAfter       string `json:"reapply_after,omitempty"`
 	CreatedAt          string `json:"created_at"`
 }
 
+type updateDispatcherApplicationRequest struct {
+	VehicleType        *string `json:"vehicle_type" binding:"omitempty"`
+	VehiclePlateNumber *string `json:"vehicle_plate_number" binding:"omitempty,min=1"`
+	VehicleYear        *int    `json:"vehicle_year" binding:"omitempty,gt=0"`
+	VehicleModel       *string `json:"vehicle_model" binding:"omitempty,min=1"`
+	DriverLicense      *string `json:"driver_license" binding:"omitempty,min=1"`
+	Region             *string `json:"region" binding:"omitempty,min=1"`
+	VehicleMake        *string `json:"vehicle_make" binding:"omitempty,min=1"`
+}
+
+type withdrawApplicationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type applicationHistoryResponse struct {
+	ID        string                        `json:"id"`
+	ActorID   string                        `json:"actor_id,omitempty"`
+	Action    string                        `json:"action"`
+	Changes   map[string]models.FieldChange `json:"changes,omitempty"`
+	Note      string                        `json:"note,omitempty"`
+	CreatedAt string                        `json:"created_at"`
+}
+
+type reviewApplicationRequest struct {
+	Decision   string `json:"decision" binding:"required,oneof=approve reject"`
+	ReasonCode string `json:"reason_code"` // required when rejecting
+	Note       string `json:"note"`
+}
+
+func newDispatcherAppResponse(application *models.DispatcherApplication) dispatcherAppResponse {
+	response := dispatcherAppResponse{
+		ID:                 application.ID,
+		UserID:             application.UserID,
+		VehicleType:        application.VehicleType,
+		VehiclePlateNumber: application.VehiclePlateNumber,
+		VehicleYear:        application.VehicleYear,
+		VehicleModel:       application.VehicleModel,
+		DriverLicense:      application.DriverLicense,
+		Region:             application.Region,
+		VehicleMake:        application.VehicleMake,
+		Status:             application.Status,
+		ReviewedBy:         application.ReviewedBy,
+		RejectionReason:    application.RejectionReason,
+		ReviewNote:         application.ReviewNote,
+		CreatedAt:          application.CreatedAt.Format(time.RFC3339),
+	}
+
+	if application.ReviewedAt != nil {
+		response.ReviewedAt = application.ReviewedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+// reapplyAfter returns when the user behind a rejected application may apply again.
+func (app *application) reapplyAfter(application *models.DispatcherApplication) time.Time {
+	reviewedAt := application.UpdatedAt
+	if application.ReviewedAt != nil {
+		reviewedAt = *application.ReviewedAt
+	}
+	return reviewedAt.Add(app.config.dispatcherApplyConfig.reapplyCooldown)
+}
+
+// checkApplicationRules validates the application against the rules of its region and
+// writes the error response itself if it does not meet them.
+func (app *application) checkApplicationRules(c *gin.Context, application *models.DispatcherApplication) bool {
+	rule, err := app.store.ApplicationRules.GetRule(c.Request.Context(), application.Region)
+	if err != nil {
+		if errors.Is(err, store.ErrApplicationRuleNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "application is invalid", "fields": eligibility.FieldErrors{"region": "applications are not accepted in this region"}})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve application rules"})
+		return false
+	}
+
+	fields, err := eligibility.Validate(rule, eligibility.Applicant{
+		VehiclePlateNumber: application.VehiclePlateNumber,
+		DriverLicense:      application.DriverLicense,
+		VehicleMake:        application.VehicleMake,
+		VehicleYear:        application.VehicleYear,
+	}, time.Now())
+	if err != nil {
+		app.logger.Errorw("invalid application rule", "region", application.Region, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate application"})
+		return false
+	}
+	if len(fields) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "application is invalid", "fields": fields})
+		return false
+	}
+
+	return true
+}
+
 // type dispatcherResponse struct {
 // 	ID                 string    `json:"id"`
 // 	UserID             string    `json:"user_id"`
@@ -48,7 +156,7 @@ type dispatcherAppResponse struct {
 // CreateDispatcherApplication godoc
 //
 //	@Summary		Create dispatcher application
-//	@Description	Apply as a dispatcher
+//	@Description	Apply as a dispatcher. The application is checked against the rules of its region; failing fields are listed in "fields"
 //	@Tags			DispatchersApply
 //	@Accept			json
 //	@Produce		json
@@ -56,6 +164,8 @@ type dispatcherAppResponse struct {
 //	@Success		201		{object}	dispatcherAppResponse
 //	@Failure		400		{object}	error
 //	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		422		{object}	error
 //	@Failure		500		{object}	error
 //	@Router			/dispatchers/apply [post]
 //
@@ -83,39 +193,322 @@ func (app *application) dispatcherApply(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check existing application"})
 		return
 	}
-	if existingApp != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user already has an application"})
-		return
+	if existingApp != nil && existingApp.Status != store.ApplicationStatusWithdrawn {
+		if existingApp.Status != store.ApplicationStatusRejected {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user already has an application"})
+			return
+		}
+
+		if reapplyAfter := app.reapplyAfter(existingApp); time.Now().Before(reapplyAfter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "application was rejected recently, try again later", "reapply_after": reapplyAfter.Format(time.RFC3339)})
+			return
+		}
 	}
 
 	apply := &models.DispatcherApplication{
 		UserID:             authUser.ID,
 		VehicleType:        payload.VehicleType,
-		VehiclePlateNumber: payload.VehiclePlateNumber,
+		VehiclePlateNumber: strings.TrimSpace(payload.VehiclePlateNumber),
 		VehicleYear:        payload.VehicleYear,
 		VehicleModel:       payload.VehicleModel,
-		DriverLicense:      payload.DriverLicense,
-		Status:             "pending",
+		DriverLicense:      strings.TrimSpace(payload.DriverLicense),
+		Region:             strings.ToLower(strings.TrimSpace(payload.Region)),
+		VehicleMake:        strings.TrimSpace(payload.VehicleMake),
+		Status:             store.ApplicationStatusPending,
+	}
+
+	if _, ok := app.activeVehicleType(c, apply.VehicleType); !ok {
+		return
+	}
+
+	if !app.checkApplicationRules(c, apply) {
+		return
 	}
 
 	submittedApplylication, err := app.store.DispatcherApplications.DispatcherApplication(c.Request.Context(), apply)
 
 	if err != nil {
+		if errors.Is(err, store.ErrApplicationExists) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user already has an application"})
+			return
+		}
+		if duplicateIdentifierResponse(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to create dispatcher application"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, dispatcherAppResponse{
-		ID:                 submittedApplylication.ID,
-		UserID:             submittedApplylication.UserID,
-		VehicleType:        submittedApplylication.VehicleType,
-		VehiclePlateNumber: submittedApplylication.VehiclePlateNumber,
-		VehicleYear:        submittedApplylication.VehicleYear,
-		VehicleModel:       submittedApplylication.VehicleModel,
-		DriverLicense:      submittedApplylication.DriverLicense,
-		Status:             submittedApplylication.Status,
-		CreatedAt:          submittedApplylication.CreatedAt.Format(time.RFC3339),
-	})
+	c.JSON(http.StatusCreated, newDispatcherAppResponse(submittedApplylication))
+}
+
+// GetMyDispatcherApplication godoc
+//
+//	@Summary		Get my dispatcher application
+//	@Description	Get the current user's latest dispatcher application, including the reason if it was rejected and when they may apply again
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	dispatcherAppResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/dispatchers/apply/me [get]
+//
+//	@Security		BearerAuth
+func (app *application) getMyApplication(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcherApp, err := app.store.DispatcherApplications.GetApplicationByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher application"})
+		return
+	}
+
+	response := newDispatcherAppResponse(dispatcherApp)
+	// applicants see the decision, not which admin made it
+	response.ReviewedBy = ""
+	if dispatcherApp.Status == store.ApplicationStatusRejected {
+		response.ReapplyAfter = app.reapplyAfter(dispatcherApp).Format(time.RFC3339)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateMyDispatcherApplication godoc
+//
+//	@Summary		Edit my dispatcher application
+//	@Description	Fix mistakes in the current user's application while it is still pending. The edited application is checked against the rules of its region again and the changes are recorded in its history
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		updateDispatcherApplicationRequest	true	"Fields to change"
+//	@Success		200		{object}	dispatcherAppResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		422		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/dispatchers/apply/me [patch]
+//
+//	@Security		BearerAuth
+func (app *application) updateMyApplication(c *gin.Context) {
+
+	var payload updateDispatcherApplicationRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcherApp, err := app.store.DispatcherApplications.GetApplicationByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher application"})
+		return
+	}
+
+	if dispatcherApp.Status != store.ApplicationStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "only pending applications can be edited"})
+		return
+	}
+
+	if payload.VehicleType != nil {
+		if _, ok := app.activeVehicleType(c, *payload.VehicleType); !ok {
+			return
+		}
+		dispatcherApp.VehicleType = *payload.VehicleType
+	}
+	if payload.VehiclePlateNumber != nil {
+		dispatcherApp.VehiclePlateNumber = strings.TrimSpace(*payload.VehiclePlateNumber)
+	}
+	if payload.VehicleYear != nil {
+		dispatcherApp.VehicleYear = *payload.VehicleYear
+	}
+	if payload.VehicleModel != nil {
+		dispatcherApp.VehicleModel = *payload.VehicleModel
+	}
+	if payload.DriverLicense != nil {
+		dispatcherApp.DriverLicense = strings.TrimSpace(*payload.DriverLicense)
+	}
+	if payload.Region != nil {
+		dispatcherApp.Region = strings.ToLower(strings.TrimSpace(*payload.Region))
+	}
+	if payload.VehicleMake != nil {
+		dispatcherApp.VehicleMake = strings.TrimSpace(*payload.VehicleMake)
+	}
+
+	if !app.checkApplicationRules(c, dispatcherApp) {
+		return
+	}
+
+	if err := app.store.DispatcherApplications.UpdateApplication(c.Request.Context(), dispatcherApp, authUser.ID); err != nil {
+		if errors.Is(err, store.ErrApplicationNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": "only pending applications can be edited"})
+			return
+		}
+		if duplicateIdentifierResponse(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update dispatcher application"})
+		return
+	}
+
+	response := newDispatcherAppResponse(dispatcherApp)
+	response.ReviewedBy = ""
+
+	c.JSON(http.StatusOK, response)
+}
+
+// WithdrawMyDispatcherApplication godoc
+//
+//	@Summary		Withdraw my dispatcher application
+//	@Description	Withdraw the current user's pending application. The application is kept with status withdrawn and the user may apply again straight away
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		withdrawApplicationRequest	false	"Withdrawal reason"
+//	@Success		200		{object}	dispatcherAppResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/dispatchers/apply/me [delete]
+//
+//	@Security		BearerAuth
+func (app *application) withdrawMyApplication(c *gin.Context) {
+
+	var payload withdrawApplicationRequest
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcherApp, err := app.store.DispatcherApplications.GetApplicationByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher application"})
+		return
+	}
+
+	withdrawn, err := app.store.DispatcherApplications.WithdrawApplication(c.Request.Context(), dispatcherApp.ID, authUser.ID, payload.Reason)
+	if err != nil {
+		if errors.Is(err, store.ErrApplicationNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": "only pending applications can be withdrawn"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to withdraw dispatcher application"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newDispatcherAppResponse(withdrawn))
+}
+
+// duplicateIdentifierResponse responds with 409 and the conflicting field when err is
+// a *store.DuplicateError.
+func duplicateIdentifierResponse(c *gin.Context, err error) bool {
+	var dupErr *store.DuplicateError
+	if !errors.As(err, &dupErr) {
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": dupErr.Error(), "field": dupErr.Field})
+	return true
+}
+
+// GetDuplicateIdentifiers godoc
+//
+//	@Summary		Get duplicate plate numbers and driver licenses
+//	@Description	Report plate numbers and driver licenses used by more than one user, ignoring case, spacing and punctuation, across all applications and dispatchers for fraud review
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.DuplicateIdentifier
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/dispatcher-applications/duplicates [get]
+//
+//	@Security		BearerAuth
+func (app *application) getDuplicateIdentifiers(c *gin.Context) {
+	duplicates, err := app.store.DispatcherApplications.GetDuplicateIdentifiers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve duplicate identifiers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, duplicates)
+}
+
+// GetDispatcherApplicationHistory godoc
+//
+//	@Summary		Get dispatcher application history
+//	@Description	Get every submission, edit, withdrawal and review of a dispatcher application, oldest first
+//	@Tags			DispatchersApply
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Dispatcher Application ID"
+//	@Success		200	{array}		applicationHistoryResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/dispatcher-applications/{id}/history [get]
+//
+//	@Security		BearerAuth
+func (app *application) getApplicationHistory(c *gin.Context) {
+
+	dispatcherApp, err := app.getDispatcherAppFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher application not found"})
+		return
+	}
+
+	entries, err := app.store.DispatcherApplications.GetApplicationHistory(c.Request.Context(), dispatcherApp.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve application history"})
+		return
+	}
+
+	response := []applicationHistoryResponse{}
+	for _, entry := range *entries {
+		response = append(response, applicationHistoryResponse{
+			ID:        entry.ID,
+			ActorID:   entry.ActorID,
+			Action:    entry.Action,
+			Changes:   entry.Changes,
+			Note:      entry.Note,
+			CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetDispatherApplications godoc
@@ -125,7 +518,16 @@ func (app *application) dispatcherApply(c *gin.Context) {
 //	@Tags			DispatchersApply
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	dispatcherAppResponse
+//	@Param			limit			query		int		false	"Page size (default 50, max 200)"
+//	@Param			cursor			query		string	false	"next_cursor of the previous page"
+//	@Param			sort			query		string	false	"created_at, updated_at or vehicle_year; prefix with - for descending (default -created_at)"
+//	@Param			status			query		string	false	"Filter by status"
+//	@Param			vehicle_type	query		string	false	"Filter by vehicle type"
+//	@Param			region			query		string	false	"Filter by region"
+//	@Param			q				query		string	false	"Search plate number, driver license and applicant username or email"
+//	@Param			created_from	query		string	false	"Created at or after (date or RFC 3339)"
+//	@Param			created_to		query		string	false	"Created before (date or RFC 3339)"
+//	@Success		200				{object}	listApplicationsResponse
 //	@Failure		400	{object}	error
 //	@Failure		404	{object}	error
 //	@Failure		500	{object}	error
@@ -145,28 +547,23 @@ func (app *application) getAllApplications(c *gin.Context) {
 		return
 	}
 
-	applications, err := app.store.DispatcherApplications.GetAllApplications(c.Request.Context())
+	q, ok := parseListQuery(c, store.ApplicationListSpec)
+	if !ok {
+		return
+	}
+
+	applications, page, err := app.store.DispatcherApplications.GetAllApplications(c.Request.Context(), q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve applications"})
 		return
 	}
 
-	var response []dispatcherAppResponse
+	response := []dispatcherAppResponse{}
 	for _, application := range *applications {
-		response = append(response, dispatcherAppResponse{
-			ID:                 application.ID,
-			UserID:             application.UserID,
-			VehicleType:        application.VehicleType,
-			VehiclePlateNumber: application.VehiclePlateNumber,
-			VehicleYear:        application.VehicleYear,
-			VehicleModel:       application.VehicleModel,
-			DriverLicense:      application.DriverLicense,
-			Status:             application.Status,
-			CreatedAt:          application.CreatedAt.Format(time.RFC3339),
-		})
+		response = append(response, newDispatcherAppResponse(&application))
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, listApplicationsResponse{Data: response, Pagination: page})
 }
 
 // GetDispatcherById godoc
@@ -203,39 +600,39 @@ func (app *application) getDispatcherApplicationById(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dispatcherAppResponse{
-		ID:                 dispatcherApp.ID,
-		UserID:             dispatcherApp.UserID,
-		VehicleType:        dispatcherApp.VehicleType,
-		VehiclePlateNumber: dispatcherApp.VehiclePlateNumber,
-		VehicleYear:        dispatcherApp.VehicleYear,
-		VehicleModel:       dispatcherApp.VehicleModel,
-		DriverLicense:      dispatcherApp.DriverLicense,
-		Status:             dispatcherApp.Status,
-		CreatedAt:          dispatcherApp.CreatedAt.Format(time.RFC3339),
-	})
+	c.JSON(http.StatusOK, newDispatcherAppResponse(dispatcherApp))
 }
 
 // ApproveOrDenyDispatcherApplication godoc
 //
-//	@Summary		Approve or Deny a dispatcher application
-//	@Description	Approve or Deny a dispatcher
+//	@Summary		Approve or reject a dispatcher application
+//	@Description	Review the user's pending dispatcher application. Rejections need a reason code and are kept so the applicant can see why
 //	@Tags			DispatchersApply
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID	path		string				true	"Dispatcher Application ID"
-//
-//	@Success		200		{object}	map[string]string	"success: application rejected"
-//	@Success		201		{object}	map[string]string	"success: application approved"
-//
+//	@Param			userID	path		string						true	"Applicant's user ID"
+//	@Param			payload	body		reviewApplicationRequest	true	"Review decision"
+//	@Success		200		{object}	dispatcherAppResponse
 //	@Failure		400		{object}	error
 //	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
 //	@Failure		500		{object}	error
 //	@Router			/admin/approve-dispatcher/{userID} [patch]
 //
 //	@Security		BearerAuth
 func (app *application) approveDenyApplication(c *gin.Context) {
 
+	var payload reviewApplicationRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if payload.Decision == "reject" && !store.IsValidRejectionReason(payload.ReasonCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a valid reason_code is required to reject an application", "reason_codes": store.ApplicationRejectionReasons})
+		return
+	}
+
 	authUser, err := app.getUserFromContext(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
@@ -253,21 +650,28 @@ func (app *application) approveDenyApplication(c *gin.Context) {
 		return
 	}
 
-	// Check if application is already approved
-	if dispatcherApp.Status == "approved" {
-		c.JSON(http.StatusOK, gin.H{"message": "Dispatch Application Already Approved"})
+	if dispatcherApp.Status != store.ApplicationStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "dispatcher application has already been reviewed"})
 		return
 	}
 
-	// Reject application based on the following logic
+	dispatcherApp.ReviewedBy = authUser.ID
+	dispatcherApp.ReviewNote = payload.Note
 
-	if dispatcherApp.Status != "pending" || len(dispatcherApp.VehiclePlateNumber) != 8 || len(dispatcherApp.DriverLicense) != 12 || dispatcherApp.VehicleYear < 2008 {
-		if err := app.store.DispatcherApplications.DeleteApplicationByUserId(c.Request.Context(), dispatcherApp.UserID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete dispatcher application"})
+	if payload.Decision == "reject" {
+		dispatcherApp.Status = store.ApplicationStatusRejected
+		dispatcherApp.RejectionReason = payload.ReasonCode
+
+		if err := app.store.DispatcherApplications.ReviewApplication(c.Request.Context(), dispatcherApp); err != nil {
+			if errors.Is(err, store.ErrApplicationAlreadyReviewed) {
+				c.JSON(http.StatusConflict, gin.H{"error": "dispatcher application has already been reviewed"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reject dispatcher application"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Dispatch Application Rejected"})
+		c.JSON(http.StatusOK, newDispatcherAppResponse(dispatcherApp))
 		return
 	}
 
@@ -285,29 +689,42 @@ func (app *application) approveDenyApplication(c *gin.Context) {
 		Rating:             0,
 	}
 
-	if err := app.store.Dispatchers.CreateDispatcher(c.Request.Context(), dispatcher); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create dispatcher"})
-		return
-	}
+	dispatcherApp.Status = store.ApplicationStatusApproved
 
-	dispatcherApp.Status = "approved"
+	// the dispatcher record, the application status and the user's role change together or not at all
+	err = app.store.WithTx(c.Request.Context(), func(tx *store.Storage) error {
+		if err := tx.DispatcherApplications.ReviewApplication(c.Request.Context(), dispatcherApp); err != nil {
+			return err
+		}
 
-	if err := app.store.DispatcherApplications.UpdateDispatchApplicationStatus(c.Request.Context(), dispatcherApp, dispatcherApp.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update dispatcher application"})
-		return
-	}
+		if err := tx.Dispatchers.CreateDispatcher(c.Request.Context(), dispatcher); err != nil {
+			return fmt.Errorf("create dispatcher: %w", err)
+		}
 
-	user, err := app.store.Users.GetUserById(c.Request.Context(), dispatcherApp.UserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
-		return
-	}
+		user, err := tx.Users.GetUserById(c.Request.Context(), dispatcherApp.UserID)
+		if err != nil {
+			return fmt.Errorf("retrieve user: %w", err)
+		}
 
-	user.Role = "dispatcher"
-	if _, err := app.store.Users.UpdateUser(c.Request.Context(), user, user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		user.Role = "dispatcher"
+		if _, err := tx.Users.UpdateUser(c.Request.Context(), user, user.ID); err != nil {
+			return fmt.Errorf("update user: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrApplicationAlreadyReviewed) {
+			c.JSON(http.StatusConflict, gin.H{"error": "dispatcher application has already been reviewed"})
+			return
+		}
+		if duplicateIdentifierResponse(c, err) {
+			return
+		}
+		app.logger.Errorw("failed to approve dispatcher application", "application_id", dispatcherApp.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to approve dispatcher application"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, "Your Dispatch Application has Been Approved")
+	c.JSON(http.StatusOK, newDispatcherAppResponse(dispatcherApp))
 }
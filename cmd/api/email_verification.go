@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/auth"
+	"github.com/puremike/pcourierds/internal/mailer"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+// sendEmailVerification emails the user a link to verify their address. It runs after
+// the response is sent, so failures are only logged.
+func (app *application) sendEmailVerification(user *models.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		app.logger.Errorw("failed to generate email verification token", "error", err)
+		return
+	}
+
+	verificationToken := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   store.UserTokenPurposeEmailVerification,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(app.config.emailVerificationConfig.tokenTTL),
+	}
+
+	if err := app.store.UserTokens.CreateUserToken(ctx, verificationToken); err != nil {
+		app.logger.Errorw("failed to save email verification token", "user_id", user.ID, "error", err)
+		return
+	}
+
+	link := app.config.apiURL + "/api/v1/auth/verify-email?token=" + url.QueryEscape(token)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n\nIf you did not create an account, you can ignore this email.\n",
+			user.Username, app.config.emailVerificationConfig.tokenTTL, link),
+	}
+
+	if err := app.mailer.Send(ctx, msg); err != nil {
+		app.logger.Errorw("failed to send email verification", "user_id", user.ID, "error", err)
+	}
+}
+
+// VerifyEmail godoc
+//
+//	@Summary		Verify email address
+//	@Description	Verify the user's email address with the token from the verification email
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			token	query		string	true	"Verification token"
+//	@Success		200		{object}	string	"email verified"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/verify-email [get]
+func (app *application) verifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	err := app.store.WithTx(c.Request.Context(), func(tx *store.Storage) error {
+		verificationToken, err := tx.UserTokens.ConsumeUserToken(c.Request.Context(), store.UserTokenPurposeEmailVerification, auth.HashOpaqueToken(token))
+		if err != nil {
+			return err
+		}
+
+		return tx.Users.MarkEmailVerified(c.Request.Context(), verificationToken.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+			return
+		}
+		app.logger.Errorw("failed to verify email", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "email verified successfully")
+}
+
+// ResendVerificationEmail godoc
+//
+//	@Summary		Resend verification email
+//	@Description	Send a new email verification link to the current user. Earlier links stop working
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		202	{object}	map[string]string
+//	@Failure		401	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		429	{object}	error
+//	@Router			/auth/verify-email/resend [post]
+//
+//	@Security		BearerAuth
+func (app *application) resendVerificationEmail(c *gin.Context) {
+	user, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "email is already verified"})
+		return
+	}
+
+	if ok, retryAfter := app.rateLimits.verificationEmail.Allow(user.ID); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests, try again later"})
+		return
+	}
+
+	go app.sendEmailVerification(user)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "a verification link has been sent to " + user.Email})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/auth"
+)
+
+// GetJWKS godoc
+//
+//	@Summary		Get JSON Web Key Set
+//	@Description	Public keys access tokens are signed with, keyed by the kid token header. Empty when tokens are signed with a shared secret
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	auth.JWKS
+//	@Router			/.well-known/jwks.json [get]
+func (app *application) getJWKS(c *gin.Context) {
+	jwks := auth.JWKS{Keys: []auth.JWK{}}
+	if publisher, ok := app.jwtAuth.(auth.KeyPublisher); ok {
+		jwks = publisher.JWKS()
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
+
+// reloadKeysOnSignal reloads the token signing keys on SIGHUP until ctx is cancelled.
+func (app *application) reloadKeysOnSignal(ctx context.Context) {
+	reloader, ok := app.jwtAuth.(auth.Reloader)
+	if !ok {
+		return
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := reloader.Reload(); err != nil {
+				app.logger.Errorw("failed to reload signing keys, keeping the current keys", "error", err)
+				continue
+			}
+			app.logger.Infow("reloaded signing keys")
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/listing"
+)
+
+type listUsersResponse struct {
+	Data       []userResponse `json:"data"`
+	Pagination *listing.Page  `json:"pagination"`
+}
+
+type listApplicationsResponse struct {
+	Data       []dispatcherAppResponse `json:"data"`
+	Pagination *listing.Page           `json:"pagination"`
+}
+
+// parseListQuery reads the pagination, filter and sort parameters of a list endpoint,
+// responding with 400 when they are invalid.
+func parseListQuery(c *gin.Context, spec listing.Spec) (*listing.Query, bool) {
+	q, err := listing.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return q, true
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+const (
+	// pings older than maxPingAge or further than maxPingClockSkew in the future are rejected
+	maxPingAge       = 24 * time.Hour
+	maxPingClockSkew = time.Minute
+
+	// only positions fresher than nearbyPositionMaxAge are considered by the nearby search
+	nearbyPositionMaxAge = 30 * time.Minute
+	defaultNearbyRadius  = 5.0
+	maxNearbyRadius      = 50.0
+)
+
+type locationPingRequest struct {
+	Lat        *float64  `json:"lat" binding:"required,gte=-90,lte=90"`
+	Lng        *float64  `json:"lng" binding:"required,gte=-180,lte=180"`
+	AccuracyM  *float64  `json:"accuracy" binding:"omitempty,gte=0"`
+	Heading    *float64  `json:"heading" binding:"omitempty,gte=0,lt=360"`
+	SpeedMps   *float64  `json:"speed" binding:"omitempty,gte=0"`
+	RecordedAt time.Time `json:"recorded_at" binding:"required"`
+}
+
+type locationBatchRequest struct {
+	Pings []locationPingRequest `json:"pings" binding:"required,min=1,max=100,dive"`
+}
+
+type dispatcherPositionResponse struct {
+	DispatcherID string   `json:"dispatcher_id"`
+	UserID       string   `json:"user_id"`
+	VehicleType  string   `json:"vehicle_type"`
+	Lat          float64  `json:"lat"`
+	Lng          float64  `json:"lng"`
+	AccuracyM    *float64 `json:"accuracy,omitempty"`
+	Heading      *float64 `json:"heading,omitempty"`
+	SpeedMps     *float64 `json:"speed,omitempty"`
+	RecordedAt   string   `json:"recorded_at"`
+	DistanceKm   float64  `json:"distance_km"`
+}
+
+// RecordLocation godoc
+//
+//	@Summary		Report dispatcher location
+//	@Description	Upload a batch of up to 100 GPS pings from the current dispatcher's device
+//	@Tags			Dispatchers
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		locationBatchRequest	true	"Location pings"
+//	@Success		202		{object}	map[string]int			"accepted pings"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/dispatchers/me/location [post]
+//
+//	@Security		BearerAuth
+func (app *application) recordLocation(c *gin.Context) {
+
+	var payload locationBatchRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcher, err := app.store.Dispatchers.GetDispatcherByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher"})
+		return
+	}
+
+	now := time.Now()
+	pings := make([]models.LocationPing, 0, len(payload.Pings))
+
+	for i, p := range payload.Pings {
+		if p.RecordedAt.After(now.Add(maxPingClockSkew)) || p.RecordedAt.Before(now.Add(-maxPingAge)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("pings[%d].recorded_at is out of range", i)})
+			return
+		}
+
+		pings = append(pings, models.LocationPing{
+			Lat:        *p.Lat,
+			Lng:        *p.Lng,
+			AccuracyM:  p.AccuracyM,
+			Heading:    p.Heading,
+			SpeedMps:   p.SpeedMps,
+			RecordedAt: p.RecordedAt.UTC(),
+		})
+	}
+
+	if err := app.store.Locations.RecordPings(c.Request.Context(), dispatcher.ID, pings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record location"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(pings)})
+}
+
+// GetNearbyDispatchers godoc
+//
+//	@Summary		Find nearby dispatchers
+//	@Description	Find active dispatchers whose recent last known position is within a radius of a point, nearest first
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			lat			query		number	true	"Latitude"
+//	@Param			lng			query		number	true	"Longitude"
+//	@Param			radius_km	query		number	false	"Search radius in km (default 5, max 50)"
+//	@Success		200			{array}		dispatcherPositionResponse
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/admin/dispatchers/nearby [get]
+//
+//	@Security		BearerAuth
+func (app *application) getNearbyDispatchers(c *gin.Context) {
+
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	center := geo.Point{Lat: lat, Lng: lng}
+
+	if latErr != nil || lngErr != nil || !center.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "valid lat and lng are required"})
+		return
+	}
+
+	radius := defaultNearbyRadius
+	if raw := c.Query("radius_km"); raw != "" {
+		r, err := strconv.ParseFloat(raw, 64)
+		if err != nil || r <= 0 || r > maxNearbyRadius {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius_km must be between 0 and %.0f", maxNearbyRadius)})
+			return
+		}
+		radius = r
+	}
+
+	positions, err := app.store.Locations.FindDispatchersWithinRadius(c.Request.Context(), center, radius, time.Now().UTC().Add(-nearbyPositionMaxAge))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find nearby dispatchers"})
+		return
+	}
+
+	response := []dispatcherPositionResponse{}
+	for _, p := range *positions {
+		response = append(response, dispatcherPositionResponse{
+			DispatcherID: p.DispatcherID,
+			UserID:       p.UserID,
+			VehicleType:  p.VehicleType,
+			Lat:          p.Lat,
+			Lng:          p.Lng,
+			AccuracyM:    p.AccuracyM,
+			Heading:      p.Heading,
+			SpeedMps:     p.SpeedMps,
+			RecordedAt:   p.RecordedAt.Format(time.RFC3339),
+			DistanceKm:   p.DistanceKm,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
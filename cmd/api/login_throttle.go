@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so a failed login
+// takes as long whether or not the account exists.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// loginThrottleSubject normalizes an email for account throttling.
+func loginThrottleSubject(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginRetryAfter returns how long logins for email from ip are still refused, or zero
+// if they are allowed.
+func (app *application) loginRetryAfter(ctx context.Context, email, ip string) (time.Duration, error) {
+	var retryAfter time.Duration
+
+	for kind, subject := range map[string]string{store.LoginThrottleAccount: loginThrottleSubject(email), store.LoginThrottleIP: ip} {
+		throttle, err := app.store.LoginThrottles.GetLoginThrottle(ctx, kind, subject)
+		if err != nil {
+			if errors.Is(err, store.ErrLoginThrottleNotFound) {
+				continue
+			}
+			return 0, err
+		}
+
+		if throttle.LockedUntil != nil {
+			retryAfter = max(retryAfter, time.Until(*throttle.LockedUntil))
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// recordLoginFailure counts a failed login against the account and the client IP.
+// Errors are only logged, so they do not change the response.
+func (app *application) recordLoginFailure(ctx context.Context, email, ip string) {
+	policies := map[string]store.ThrottlePolicy{
+		store.LoginThrottleAccount: app.config.loginThrottleConfig.account,
+		store.LoginThrottleIP:      app.config.loginThrottleConfig.ip,
+	}
+	subjects := map[string]string{store.LoginThrottleAccount: loginThrottleSubject(email), store.LoginThrottleIP: ip}
+
+	for kind, subject := range subjects {
+		throttle, err := app.store.LoginThrottles.RecordLoginFailure(ctx, kind, subject, policies[kind])
+		if err != nil {
+			app.logger.Errorw("failed to record login failure", "kind", kind, "error", err)
+			continue
+		}
+
+		if throttle.Failures >= policies[kind].LockoutAfter {
+			app.logger.Warnw("logins locked after repeated failures", "kind", kind, "subject", subject, "failures", throttle.Failures, "locked_until", throttle.LockedUntil)
+		}
+	}
+}
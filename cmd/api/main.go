@@ -1,29 +1,134 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/puremike/pcourierds/internal/auth"
+	"github.com/puremike/pcourierds/internal/blob"
 	"github.com/puremike/pcourierds/internal/db"
 	"github.com/puremike/pcourierds/internal/env"
+	"github.com/puremike/pcourierds/internal/mailer"
+	"github.com/puremike/pcourierds/internal/pricing"
+	"github.com/puremike/pcourierds/internal/ratelimit"
 	"github.com/puremike/pcourierds/internal/store"
 	"go.uber.org/zap"
 )
 
 type application struct {
-	config  *config
-	logger  *zap.SugaredLogger
-	store   *store.Storage
-	jwtAuth *auth.JWTAuthenticator
+	config        *config
+	logger        *zap.SugaredLogger
+	store         *store.Storage
+	jwtAuth       auth.Authenticator
+	revokedTokens *auth.RevocationCache
+	quoteSigner   *pricing.Signer
+	blobs         blob.Storage
+	documentURLs  *blob.URLSigner
+	mailer        mailer.Mailer
+	mfaSecrets    *auth.SecretBox
+	rateLimits    rateLimits
+}
+
+type rateLimits struct {
+	forgotPasswordIP    *ratelimit.Limiter
+	forgotPasswordEmail *ratelimit.Limiter
+	resetPasswordIP     *ratelimit.Limiter
+	verificationEmail   *ratelimit.Limiter
+	trackingIP          *ratelimit.Limiter
 }
 
 type config struct {
-	port            string
-	env             string
-	dbconfig        dbconfig
-	authConfig      authConfig
-	basicAuthConfig basicAuthConfig
+	port                    string
+	env                     string
+	dbconfig                dbconfig
+	authConfig              authConfig
+	basicAuthConfig         basicAuthConfig
+	quoteConfig             quoteConfig
+	offerConfig             offerConfig
+	blobConfig              blobConfig
+	deliveryConfig          deliveryConfig
+	dispatcherApplyConfig   dispatcherApplyConfig
+	documentConfig          documentConfig
+	mailerConfig            mailerConfig
+	passwordResetConfig     passwordResetConfig
+	emailVerificationConfig emailVerificationConfig
+	mfaConfig               mfaConfig
+	cookieConfig            cookieConfig
+	loginThrottleConfig     loginThrottleConfig
+	frontendURL             string   // base URL of the web app, used for links in emails
+	apiURL                  string   // public base URL of this API, used for links in emails
+	trustedProxies          []string // addresses or CIDRs whose X-Forwarded-For is believed; none by default
+}
+
+type mailerConfig struct {
+	smtpHost     string
+	smtpPort     int
+	smtpUsername string
+	smtpPassword string
+	from         string
+	dir          string // where messages are written when SMTP is not configured
+}
+
+type passwordResetConfig struct {
+	tokenTTL time.Duration
+}
+
+type loginThrottleConfig struct {
+	account store.ThrottlePolicy
+	ip      store.ThrottlePolicy
+}
+
+type cookieConfig struct {
+	domain   string // empty for host-only cookies
+	secure   bool
+	sameSite http.SameSite
+}
+
+type mfaConfig struct {
+	issuer        string // shown in authenticator apps
+	encryptionKey string // hex encoded 32 byte key TOTP secrets are encrypted with
+	challengeTTL  time.Duration
+	requireAdmin  bool // admin routes need a login that completed two-factor authentication
+	attempts      store.ThrottlePolicy
+}
+
+type emailVerificationConfig struct {
+	required bool // block unverified users from booking packages and applying as dispatchers
+	tokenTTL time.Duration
+}
+
+type documentConfig struct {
+	urlSecret string
+	urlTTL    time.Duration
+	maxBytes  int64
+}
+
+type dispatcherApplyConfig struct {
+	reapplyCooldown time.Duration
+}
+
+type blobConfig struct {
+	dir string
+}
+
+type deliveryConfig struct {
+	maxPinAttempts int
+}
+
+type offerConfig struct {
+	ttl           time.Duration
+	sweepInterval time.Duration
+	reofferAfter  time.Duration // how long a dispatcher who declined or missed a package is skipped for it
+}
+
+type quoteConfig struct {
+	secret string
+	ttl    time.Duration
 }
 
 type basicAuthConfig struct {
@@ -32,7 +137,11 @@ type basicAuthConfig struct {
 
 type authConfig struct {
 	secret, iss, aud string
+	keysDir          string // directory of <kid>.pem keys for RS256/EdDSA signing; JWT_SECRET (HS256) is used when empty
+	signingKeyID     string // kid of the key in keysDir that signs new tokens, unless keysDir has a signing_key_id file
 	tokenExp         time.Duration
+	refreshTokenExp  time.Duration
+	revocationTTL    time.Duration // how long a token found not to be revoked skips the denylist lookup
 }
 
 type dbconfig struct {
@@ -61,6 +170,11 @@ const apiVersion = "1.1.0"
 // @in							header
 // @name						Authorization
 // @description				Use a valid JWT token. Format: Bearer <token>
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				API key for integrations, limited to the scopes it was created with
 func main() {
 
 	cfg := &config{
@@ -73,18 +187,97 @@ func main() {
 			connsMaxIdleTime: env.GetEnvTDuration("SET_CONN_MAX_IDLE_TIME", 25*time.Minute),
 		},
 		authConfig: authConfig{
-			secret: env.GetEnvString("JWT_SECRET", "1201fd4db595d3d9341e5c954e0d93f07a986380580f11da93cc4dfc942c3988"),
-			iss:    env.GetEnvString("JWT_ISS", "pcourierds"),
-			aud:    env.GetEnvString("JWT_AUD", "pcourierds"),
+			iss:          env.GetEnvString("JWT_ISS", "pcourierds"),
+			aud:          env.GetEnvString("JWT_AUD", "pcourierds"),
+			keysDir:      env.GetEnvString("JWT_KEYS_DIR", ""),
+			signingKeyID: env.GetEnvString("JWT_SIGNING_KEY_ID", ""),
 			tokenExp: env.GetEnvTDuration(
 				"JWT_TOKEN_EXP",
 				30*time.Minute,
 			),
+			refreshTokenExp: env.GetEnvTDuration("REFRESH_TOKEN_EXP", 7*24*time.Hour),
+			revocationTTL:   env.GetEnvTDuration("TOKEN_REVOCATION_CACHE_TTL", 30*time.Second),
 		},
 		basicAuthConfig: basicAuthConfig{
 			username: env.GetEnvString("BASIC_AUTH_USERNAME", "pcourierds"),
 			password: env.GetEnvString("BASIC_AUTH_PASSWORD", "adcsdcpfdfcsggffgfgourierds"),
 		},
+		quoteConfig: quoteConfig{
+			ttl: env.GetEnvTDuration("QUOTE_TTL", 15*time.Minute),
+		},
+		offerConfig: offerConfig{
+			ttl:           env.GetEnvTDuration("OFFER_TTL", 2*time.Minute),
+			sweepInterval: env.GetEnvTDuration("OFFER_SWEEP_INTERVAL", 15*time.Second),
+			reofferAfter:  env.GetEnvTDuration("OFFER_REOFFER_COOLDOWN", 30*time.Minute),
+		},
+		blobConfig: blobConfig{
+			dir: env.GetEnvString("BLOB_STORAGE_DIR", "./data/blobs"),
+		},
+		deliveryConfig: deliveryConfig{
+			maxPinAttempts: env.GetEnvInt("DELIVERY_PIN_MAX_ATTEMPTS", 5),
+		},
+		dispatcherApplyConfig: dispatcherApplyConfig{
+			reapplyCooldown: env.GetEnvTDuration("DISPATCHER_REAPPLY_COOLDOWN", 30*24*time.Hour),
+		},
+		documentConfig: documentConfig{
+			urlTTL:   env.GetEnvTDuration("DOCUMENT_URL_TTL", 5*time.Minute),
+			maxBytes: int64(env.GetEnvInt("DOCUMENT_MAX_BYTES", 10<<20)),
+		},
+		mailerConfig: mailerConfig{
+			smtpHost:     env.GetEnvString("SMTP_HOST", ""),
+			smtpPort:     env.GetEnvInt("SMTP_PORT", 587),
+			smtpUsername: env.GetEnvString("SMTP_USERNAME", ""),
+			smtpPassword: env.GetEnvString("SMTP_PASSWORD", ""),
+			from:         env.GetEnvString("MAIL_FROM", "pcourierds <no-reply@pcourierds.local>"),
+			dir:          env.GetEnvString("MAIL_DIR", "./data/mail"),
+		},
+		passwordResetConfig: passwordResetConfig{
+			tokenTTL: env.GetEnvTDuration("PASSWORD_RESET_TOKEN_TTL", 30*time.Minute),
+		},
+		emailVerificationConfig: emailVerificationConfig{
+			required: env.GetEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+			tokenTTL: env.GetEnvTDuration("EMAIL_VERIFICATION_TOKEN_TTL", 24*time.Hour),
+		},
+		mfaConfig: mfaConfig{
+			issuer:        env.GetEnvString("MFA_ISSUER", "pcourierds"),
+			encryptionKey: env.GetEnvString("MFA_ENCRYPTION_KEY", ""),
+			challengeTTL:  env.GetEnvTDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+			requireAdmin:  env.GetEnvBool("REQUIRE_ADMIN_MFA", false),
+			// every code attempt counts, so there is no backoff between the free ones and the lockout
+			attempts: store.ThrottlePolicy{
+				FreeAttempts:    env.GetEnvInt("MFA_MAX_ATTEMPTS", 5),
+				LockoutAfter:    env.GetEnvInt("MFA_MAX_ATTEMPTS", 5) + 1,
+				LockoutDuration: env.GetEnvTDuration("MFA_LOCKOUT_DURATION", 5*time.Minute),
+				Window:          env.GetEnvTDuration("MFA_LOCKOUT_DURATION", 5*time.Minute),
+			},
+		},
+		loginThrottleConfig: loginThrottleConfig{
+			account: store.ThrottlePolicy{
+				FreeAttempts:    env.GetEnvInt("LOGIN_ACCOUNT_FREE_ATTEMPTS", 3),
+				LockoutAfter:    env.GetEnvInt("LOGIN_ACCOUNT_LOCKOUT_AFTER", 10),
+				BaseDelay:       env.GetEnvTDuration("LOGIN_BACKOFF_BASE", time.Second),
+				MaxDelay:        env.GetEnvTDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+				LockoutDuration: env.GetEnvTDuration("LOGIN_ACCOUNT_LOCKOUT_DURATION", 30*time.Minute),
+				Window:          env.GetEnvTDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+			},
+			// an IP can be shared by many users behind NAT, so it gets more attempts
+			ip: store.ThrottlePolicy{
+				FreeAttempts:    env.GetEnvInt("LOGIN_IP_FREE_ATTEMPTS", 20),
+				LockoutAfter:    env.GetEnvInt("LOGIN_IP_LOCKOUT_AFTER", 100),
+				BaseDelay:       env.GetEnvTDuration("LOGIN_BACKOFF_BASE", time.Second),
+				MaxDelay:        env.GetEnvTDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+				LockoutDuration: env.GetEnvTDuration("LOGIN_IP_LOCKOUT_DURATION", time.Hour),
+				Window:          env.GetEnvTDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+			},
+		},
+		cookieConfig: cookieConfig{
+			domain:   env.GetEnvString("COOKIE_DOMAIN", ""),
+			secure:   env.GetEnvBool("COOKIE_SECURE", false),
+			sameSite: parseSameSite(env.GetEnvString("COOKIE_SAMESITE", "lax")),
+		},
+		frontendURL:    strings.TrimSuffix(env.GetEnvString("FRONTEND_URL", "http://localhost:3000"), "/"),
+		apiURL:         strings.TrimSuffix(env.GetEnvString("API_URL", "http://localhost:5100"), "/"),
+		trustedProxies: env.GetEnvList("TRUSTED_PROXIES", nil),
 	}
 
 	logger := zap.NewExample().Sugar()
@@ -98,13 +291,107 @@ func main() {
 
 	logger.Infow("Connected to database successfully")
 
+	if cfg.cookieConfig.sameSite == http.SameSiteNoneMode && !cfg.cookieConfig.secure {
+		// browsers drop SameSite=None cookies that are not Secure
+		logger.Warnw("COOKIE_SAMESITE is none, marking session cookies secure")
+		cfg.cookieConfig.secure = true
+	}
+
+	var jwtAuth auth.Authenticator
+	if cfg.authConfig.keysDir != "" {
+		if jwtAuth, err = auth.NewKeySetAuthenticator(cfg.authConfig.keysDir, cfg.authConfig.signingKeyID, cfg.authConfig.iss, cfg.authConfig.aud); err != nil {
+			logger.Fatal(err)
+		}
+	} else {
+		cfg.authConfig.secret = requiredSecret(cfg, logger, "JWT_SECRET")
+		jwtAuth = auth.NewJWTAuthenticator(cfg.authConfig.secret, cfg.authConfig.iss, cfg.authConfig.aud)
+	}
+
+	cfg.quoteConfig.secret = requiredSecret(cfg, logger, "QUOTE_SECRET")
+	cfg.documentConfig.urlSecret = requiredSecret(cfg, logger, "DOCUMENT_URL_SECRET")
+
+	blobs, err := blob.NewLocalStorage(cfg.blobConfig.dir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// there is no per-process fallback: a generated key would make every enrolled
+	// authenticator unreadable after a restart and on other instances
+	var mfaSecrets *auth.SecretBox
+	if cfg.mfaConfig.encryptionKey == "" {
+		if cfg.mfaConfig.requireAdmin {
+			logger.Fatalw("REQUIRE_ADMIN_MFA is set but MFA_ENCRYPTION_KEY is not, generate one with: openssl rand -hex 32")
+		}
+		logger.Warnw("MFA_ENCRYPTION_KEY is not set, two-factor enrollment and verification are unavailable")
+	} else {
+		mfaKey, err := hex.DecodeString(cfg.mfaConfig.encryptionKey)
+		if err != nil {
+			logger.Fatalw("MFA_ENCRYPTION_KEY must be hex encoded", "error", err)
+		}
+
+		if mfaSecrets, err = auth.NewSecretBox(mfaKey); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var mail mailer.Mailer
+	if cfg.mailerConfig.smtpHost != "" {
+		mail = mailer.NewSMTPMailer(cfg.mailerConfig.smtpHost, cfg.mailerConfig.smtpPort, cfg.mailerConfig.smtpUsername, cfg.mailerConfig.smtpPassword, cfg.mailerConfig.from)
+	} else {
+		logger.Warnw("SMTP_HOST is not set, writing emails to disk instead of sending them", "dir", cfg.mailerConfig.dir)
+		if mail, err = mailer.NewFileMailer(cfg.mailerConfig.dir, cfg.mailerConfig.from); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
 	app := &application{
-		config:  cfg,
-		logger:  logger,
-		store:   store.NewStorage(db),
-		jwtAuth: auth.NewJWTAuthenticator(cfg.authConfig.secret, cfg.authConfig.iss, cfg.authConfig.aud),
+		config:        cfg,
+		logger:        logger,
+		store:         store.NewStorage(db),
+		jwtAuth:       jwtAuth,
+		revokedTokens: auth.NewRevocationCache(cfg.authConfig.revocationTTL),
+		quoteSigner:   pricing.NewSigner(cfg.quoteConfig.secret),
+		blobs:         blobs,
+		documentURLs:  blob.NewURLSigner(cfg.documentConfig.urlSecret),
+		mailer:        mail,
+		mfaSecrets:    mfaSecrets,
+		rateLimits: rateLimits{
+			// the per address limit is applied silently so it does not reveal which accounts exist
+			forgotPasswordIP:    ratelimit.New(5, 15*time.Minute),
+			forgotPasswordEmail: ratelimit.New(3, time.Hour),
+			resetPasswordIP:     ratelimit.New(10, 15*time.Minute),
+			verificationEmail:   ratelimit.New(3, time.Hour),
+			// tracking codes are guessable by brute force, so lookups are capped per address
+			trackingIP: ratelimit.New(60, time.Minute),
+		},
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go app.runOfferSweeper(ctx)
+	go app.reloadKeysOnSignal(ctx)
+
 	mux := app.routes()
 	logger.Fatal(app.server(mux))
 }
+
+// requiredSecret reads a signing secret from the environment variable name. Anything
+// signed with a per-process secret is rejected by other instances and after a restart,
+// so a random one is only generated in development and startup fails everywhere else.
+func requiredSecret(cfg *config, logger *zap.SugaredLogger, name string) string {
+	if secret := env.GetEnvString(name, ""); secret != "" {
+		return secret
+	}
+
+	if cfg.env != "development" {
+		logger.Fatalw(name+" is not set, generate one with: openssl rand -hex 32", "env", cfg.env)
+	}
+
+	logger.Warnw(name + " is not set, using a random secret for this process")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		logger.Fatal(err)
+	}
+	return hex.EncodeToString(secret)
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/puremike/pcourierds/internal/auth"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recoveryCodeCount is how many recovery codes are issued at a time.
+const recoveryCodeCount = 10
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"` // seconds
+}
+
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+type mfaEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type mfaDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// mfaChallengeAudience is the audience of MFA challenges. It differs from the access
+// token audience, so services verifying tokens with the published keys do not accept a
+// challenge, which only proves the password step, as an access token.
+func (app *application) mfaChallengeAudience() string {
+	return app.config.authConfig.aud + "/mfa"
+}
+
+// issueMFAChallenge signs a short-lived token proving user passed the password step.
+// It is exchanged for an access token at /auth/login/mfa.
+func (app *application) issueMFAChallenge(user *models.User) (string, error) {
+	now := time.Now()
+
+	jti, err := auth.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"jti": jti,
+		"sub": user.ID,
+		"typ": "mfa",
+		"iss": app.config.authConfig.iss,
+		"aud": app.mfaChallengeAudience(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(app.config.mfaConfig.challengeTTL).Unix(),
+	}
+
+	return app.jwtAuth.GenerateToken(claims)
+}
+
+// checkMFACode reports whether code is a valid TOTP code or unused recovery code for
+// mfa, using it up so it cannot be presented again.
+func (app *application) checkMFACode(ctx context.Context, mfa *models.UserMFA, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 6 && strings.Trim(code, "0123456789") == "" {
+		secret, err := app.mfaSecrets.Open(mfa.SecretCiphertext)
+		if err != nil {
+			return false, err
+		}
+
+		step, ok := auth.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+
+		if err := app.store.MFA.UseTOTPStep(ctx, mfa.UserID, step); err != nil {
+			if errors.Is(err, store.ErrMFACodeReused) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	if err := app.store.MFA.UseRecoveryCode(ctx, mfa.UserID, auth.HashRecoveryCode(code)); err != nil {
+		if errors.Is(err, store.ErrMFARecoveryCodeInvalid) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// requireMFAConfigured responds with 503 on two-factor routes when MFA_ENCRYPTION_KEY is
+// not set, as TOTP secrets can then be neither stored nor checked.
+func (app *application) requireMFAConfigured() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if app.mfaSecrets == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "two-factor authentication is not available"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// allowMFAAttempt counts a two-factor code attempt against the user before the code is
+// checked, responding with 429 once the limit is exceeded. Attempts are counted in the
+// database, so the limit holds across instances and concurrent requests.
+func (app *application) allowMFAAttempt(c *gin.Context, userId string) bool {
+	throttle, err := app.store.LoginThrottles.RecordLoginFailure(c.Request.Context(), store.LoginThrottleMFA, userId, app.config.mfaConfig.attempts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check code"})
+		return false
+	}
+
+	if throttle.LockedUntil != nil && time.Now().Before(*throttle.LockedUntil) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*throttle.LockedUntil).Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many attempts, try again later"})
+		return false
+	}
+	return true
+}
+
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes = make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
+
+// LoginMFA godoc
+//
+//	@Summary		Complete two-factor login
+//	@Description	Exchange the mfa_token returned by /auth/login and a TOTP or recovery code for an access token and a refresh token
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		mfaLoginRequest	true	"MFA token and code"
+//	@Success		200		{object}	loginResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Failure		503		{object}	error
+//	@Router			/auth/login/mfa [post]
+func (app *application) loginMFA(c *gin.Context) {
+	var payload mfaLoginRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := app.jwtAuth.ValidateTokenForAudience(payload.MFAToken, app.mfaChallengeAudience())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+		return
+	}
+
+	claims, _ := challenge.Claims.(jwt.MapClaims)
+	userId, _ := claims["sub"].(string)
+	if typ, _ := claims["typ"].(string); typ != "mfa" || userId == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+		return
+	}
+
+	user, err := app.store.Users.GetUserById(c.Request.Context(), userId)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
+		return
+	}
+
+	// a challenge issued before the user's tokens were revoked, e.g. by a password
+	// reset, no longer counts as a passed password check
+	if user.TokensValidAfter != nil {
+		issuedAt, err := claims.GetIssuedAt()
+		if err != nil || issuedAt == nil || issuedAt.Before(*user.TokensValidAfter) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+			return
+		}
+	}
+
+	if !app.allowMFAAttempt(c, user.ID) {
+		return
+	}
+
+	mfa, err := app.store.MFA.GetMFA(c.Request.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrMFANotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve two-factor settings"})
+		return
+	}
+
+	if mfa.EnabledAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token"})
+		return
+	}
+
+	ok, err := app.checkMFACode(c.Request.Context(), mfa, payload.Code)
+	if err != nil {
+		app.logger.Errorw("failed to check two-factor code", "user_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check code"})
+		return
+	}
+
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+
+	token, err := app.issueAccessToken(user, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	refreshToken, err := app.issueRefreshToken(c.Request.Context(), &models.RefreshToken{UserID: user.ID, MFAVerified: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	if err := app.setSessionCookies(c, token, refreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate csrf token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse{ID: user.ID, Username: user.Username, Token: token, RefreshToken: refreshToken})
+}
+
+// EnrollMFA godoc
+//
+//	@Summary		Start two-factor enrollment
+//	@Description	Generate a TOTP secret for the current user. Two-factor authentication is enabled once a code from it is confirmed
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	mfaEnrollResponse
+//	@Failure		401	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Failure		503	{object}	error
+//	@Router			/auth/mfa/enroll [post]
+//
+//	@Security		BearerAuth
+func (app *application) enrollMFA(c *gin.Context) {
+	user, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+
+	sealed, err := app.mfaSecrets.Seal(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+
+	if _, err := app.store.MFA.StartMFAEnrollment(c.Request.Context(), user.ID, sealed); err != nil {
+		if errors.Is(err, store.ErrMFAAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mfaEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(app.config.mfaConfig.issuer, user.Email, secret),
+	})
+}
+
+// ConfirmMFA godoc
+//
+//	@Summary		Confirm two-factor enrollment
+//	@Description	Enable two-factor authentication with a code from the enrolled authenticator. Returns recovery codes, which are only shown once. Log in again for a two-factor session
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		mfaCodeRequest	true	"TOTP code"
+//	@Success		200		{object}	recoveryCodesResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Failure		503		{object}	error
+//	@Router			/auth/mfa/enroll/confirm [post]
+//
+//	@Security		BearerAuth
+func (app *application) confirmMFA(c *gin.Context) {
+	var payload mfaCodeRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if !app.allowMFAAttempt(c, user.ID) {
+		return
+	}
+
+	mfa, err := app.store.MFA.GetMFA(c.Request.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrMFANotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no two-factor enrollment in progress"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve two-factor settings"})
+		return
+	}
+
+	if mfa.EnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrMFAAlreadyEnabled.Error()})
+		return
+	}
+
+	secret, err := app.mfaSecrets.Open(mfa.SecretCiphertext)
+	if err != nil {
+		app.logger.Errorw("failed to decrypt two-factor secret", "user_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to confirm enrollment"})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(secret, strings.TrimSpace(payload.Code), time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+
+	if err := app.store.MFA.EnableMFA(c.Request.Context(), user.ID, step, hashes); err != nil {
+		if errors.Is(err, store.ErrMFAAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to confirm enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes godoc
+//
+//	@Summary		Regenerate recovery codes
+//	@Description	Replace the current user's recovery codes. Earlier codes stop working
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		mfaCodeRequest	true	"TOTP code or recovery code"
+//	@Success		200		{object}	recoveryCodesResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Failure		503		{object}	error
+//	@Router			/auth/mfa/recovery-codes [post]
+//
+//	@Security		BearerAuth
+func (app *application) regenerateRecoveryCodes(c *gin.Context) {
+	var payload mfaCodeRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, mfa, ok := app.enabledMFAFromContext(c)
+	if !ok {
+		return
+	}
+
+	if !app.allowMFAAttempt(c, user.ID) {
+		return
+	}
+
+	valid, err := app.checkMFACode(c.Request.Context(), mfa, payload.Code)
+	if err != nil {
+		app.logger.Errorw("failed to check two-factor code", "user_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check code"})
+		return
+	}
+
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+
+	if err := app.store.MFA.ReplaceRecoveryCodes(c.Request.Context(), user.ID, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA godoc
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Turn off two-factor authentication for the current user. Requires the password and a TOTP or recovery code
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		mfaDisableRequest	true	"Password and code"
+//	@Success		200		{object}	string				"two-factor authentication disabled"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Failure		503		{object}	error
+//	@Router			/auth/mfa/disable [post]
+//
+//	@Security		BearerAuth
+func (app *application) disableMFA(c *gin.Context) {
+	var payload mfaDisableRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, mfa, ok := app.enabledMFAFromContext(c)
+	if !ok {
+		return
+	}
+
+	if !app.allowMFAAttempt(c, user.ID) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid password or code"})
+		return
+	}
+
+	valid, err := app.checkMFACode(c.Request.Context(), mfa, payload.Code)
+	if err != nil {
+		app.logger.Errorw("failed to check two-factor code", "user_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check code"})
+		return
+	}
+
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid password or code"})
+		return
+	}
+
+	if err := app.store.MFA.DisableMFA(c.Request.Context(), user.ID); err != nil && !errors.Is(err, store.ErrMFANotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "two-factor authentication disabled")
+}
+
+// enabledMFAFromContext returns the current user and their enabled authenticator,
+// responding with an error if there is none.
+func (app *application) enabledMFAFromContext(c *gin.Context) (*models.User, *models.UserMFA, bool) {
+	user, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, nil, false
+	}
+
+	mfa, err := app.store.MFA.GetMFA(c.Request.Context(), user.ID)
+	if err != nil && !errors.Is(err, store.ErrMFANotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve two-factor settings"})
+		return nil, nil, false
+	}
+
+	if mfa == nil || mfa.EnabledAt == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "two-factor authentication is not enabled"})
+		return nil, nil, false
+	}
+
+	return user, mfa, true
+}
@@ -4,13 +4,17 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/ratelimit"
 	"github.com/puremike/pcourierds/internal/store"
 )
 
@@ -59,23 +63,32 @@ func (app *application) basicAuthentication() gin.HandlerFunc {
 func (app *application) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		authHeader := c.GetHeader("Authorization")
+		var token string
 
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
-			c.Abort()
-			return
-		}
+		// bearer tokens are used by mobile clients, the session cookie by browsers
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is deformed"})
+				c.Abort()
+				return
+			}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is deformed"})
+			token = strings.TrimSpace(parts[1])
+		} else if cookie, err := c.Cookie(accessTokenCookie); err == nil && cookie != "" {
+			if !isSafeMethod(c.Request.Method) && !validCSRF(c) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "missing or invalid csrf token"})
+				c.Abort()
+				return
+			}
+
+			token = cookie
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
 			c.Abort()
 			return
 		}
 
-		token := strings.TrimSpace(parts[1])
-
 		jwtToken, err := app.jwtAuth.ValidateToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -97,6 +110,40 @@ func (app *application) authMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// tokens with a typ, such as MFA challenges, are not access tokens
+		jti, ok := claims["jti"].(string)
+		if _, typed := claims["typ"]; !ok || jti == "" || typed {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Abort()
+			return
+		}
+
+		expiresAt, err := claims.GetExpirationTime()
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Abort()
+			return
+		}
+
+		revoked, cached := app.revokedTokens.Lookup(jti)
+		if !cached {
+			if revoked, err = app.store.RevokedTokens.IsTokenRevoked(c.Request.Context(), jti); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				app.revokedTokens.Add(jti, expiresAt.Time)
+			} else {
+				app.revokedTokens.AddValid(jti, expiresAt.Time)
+			}
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		user, err := app.store.Users.GetUserById(c.Request.Context(), userId)
 		if err != nil {
 			if errors.Is(err, store.ErrUserNotFound) {
@@ -109,8 +156,68 @@ func (app *application) authMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if user.TokensValidAfter != nil {
+			issuedAt, err := claims.GetIssuedAt()
+			if err != nil || issuedAt == nil || issuedAt.Before(*user.TokensValidAfter) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("user", user)
 		c.Set("userId", user.ID)
+		c.Set("tokenId", jti)
+		c.Set("tokenExpiresAt", expiresAt.Time)
+		c.Set("mfaVerified", hasAuthMethod(claims, "otp"))
+		c.Next()
+	}
+}
+
+// hasAuthMethod reports whether the token's amr claim lists method.
+func hasAuthMethod(claims jwt.MapClaims, method string) bool {
+	amr, _ := claims["amr"].([]any)
+	for _, m := range amr {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitByIP rejects requests from a client IP over the limiter's limit with 429.
+func (app *application) rateLimitByIP(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, retryAfter := limiter.Allow(c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests, try again later"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireVerifiedEmail blocks users who have not verified their email address when
+// REQUIRE_EMAIL_VERIFICATION is enabled.
+func (app *application) requireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !app.config.emailVerificationConfig.required {
+			c.Next()
+			return
+		}
+
+		user, err := app.getUserFromContext(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email address is not verified"})
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -124,6 +231,10 @@ func (app *application) authorizeRoles(allowedRoles ...string) gin.HandlerFunc {
 		}
 
 		if slices.Contains(allowedRoles, user.Role) {
+			if app.adminNeedsMFA(c, user) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication is required for admin access"})
+				return
+			}
 			c.Next()
 			return
 		}
@@ -132,6 +243,31 @@ func (app *application) authorizeRoles(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// requireAdminMFA applies REQUIRE_ADMIN_MFA to routes open to every role, such as
+// creating API keys, so an admin's password alone cannot mint credentials.
+func (app *application) requireAdminMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := app.getUserFromContext(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		if app.adminNeedsMFA(c, user) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication is required for admin access"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// adminNeedsMFA reports whether the user is an admin who has to sign in with a second
+// factor before acting as one.
+func (app *application) adminNeedsMFA(c *gin.Context, user *models.User) bool {
+	return user.Role == "admin" && app.config.mfaConfig.requireAdmin && !c.GetBool("mfaVerified")
+}
+
 func (app *application) getDispatcherAppMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -179,3 +315,26 @@ func (app *application) getDispatcherAppByUserIdMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func (app *application) getPackageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		id := c.Param("id")
+
+		pack, err := app.store.Packages.GetPackageById(c.Request.Context(), id)
+
+		if err != nil {
+			if errors.Is(err, store.ErrPackageNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve package"})
+			c.Abort()
+			return
+		}
+
+		c.Set("package", pack)
+		c.Next()
+	}
+}
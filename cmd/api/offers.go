@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+// offerSweepBatch bounds how many offers are expired, and packages re-offered, per sweep.
+const offerSweepBatch = 50
+
+type offerPackageResponse struct {
+	Origin      string  `json:"origin"`
+	Destination string  `json:"destination"`
+	WeightKg    float64 `json:"weight_kg"`
+	VehicleType string  `json:"vehicle_type"`
+}
+
+type offerResponse struct {
+	ID          string                `json:"id"`
+	PackageID   string                `json:"package_id"`
+	Status      string                `json:"status"`
+	ExpiresAt   string                `json:"expires_at"`
+	RespondedAt string                `json:"responded_at,omitempty"`
+	CreatedAt   string                `json:"created_at"`
+	Package     *offerPackageResponse `json:"package,omitempty"`
+}
+
+func newOfferResponse(offer *models.DispatcherOffer) offerResponse {
+	response := offerResponse{
+		ID:        offer.ID,
+		PackageID: offer.PackageID,
+		Status:    offer.Status,
+		ExpiresAt: offer.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: offer.CreatedAt.Format(time.RFC3339),
+	}
+
+	if offer.RespondedAt != nil {
+		response.RespondedAt = offer.RespondedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+// offerPackage offers a package to the next candidate dispatcher. Running out of
+// candidates is expected and only logged; the sweeper will try again later.
+func (app *application) offerPackage(ctx context.Context, packageId string) (*models.DispatcherOffer, error) {
+	offer, err := app.store.Assignments.OfferPackage(ctx, packageId, app.config.offerConfig.ttl, app.config.offerConfig.reofferAfter)
+	if err != nil {
+		if errors.Is(err, store.ErrNoDispatcherAvailable) {
+			app.logger.Infow("no dispatcher available for package", "package_id", packageId)
+		}
+		return nil, err
+	}
+
+	app.logger.Infow("package offered to dispatcher", "package_id", packageId, "dispatcher_id", offer.DispatcherID, "offer_id", offer.ID)
+	return offer, nil
+}
+
+// runOfferSweeper periodically expires overdue offers and offers every package still
+// waiting for a dispatcher to the next candidate, until ctx is cancelled.
+func (app *application) runOfferSweeper(ctx context.Context) {
+	ticker := time.NewTicker(app.config.offerConfig.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			app.sweepOffers(ctx)
+		}
+	}
+}
+
+func (app *application) sweepOffers(ctx context.Context) {
+	expired, err := app.store.Assignments.ExpireOffers(ctx, offerSweepBatch)
+	if err != nil {
+		app.logger.Errorw("failed to expire offers", "error", err)
+		return
+	}
+	if len(expired) > 0 {
+		app.logger.Infow("expired dispatcher offers", "count", len(expired))
+	}
+
+	waiting, err := app.store.Assignments.GetUnofferedPackageIds(ctx, offerSweepBatch)
+	if err != nil {
+		app.logger.Errorw("failed to retrieve packages awaiting an offer", "error", err)
+		return
+	}
+
+	for _, packageId := range waiting {
+		var transitionErr *store.InvalidTransitionError
+		if _, err := app.offerPackage(ctx, packageId); err != nil &&
+			!errors.Is(err, store.ErrNoDispatcherAvailable) &&
+			!errors.Is(err, store.ErrOfferPending) &&
+			!errors.Is(err, store.ErrPackageAlreadyAssigned) &&
+			!errors.As(err, &transitionErr) {
+			app.logger.Errorw("failed to offer package", "package_id", packageId, "error", err)
+		}
+	}
+}
+
+// currentDispatcher resolves the dispatcher record of the authenticated user and writes
+// the error response itself when it cannot.
+func (app *application) currentDispatcher(c *gin.Context) (*models.User, *models.Dispatcher, bool) {
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, nil, false
+	}
+
+	dispatcher, err := app.store.Dispatchers.GetDispatcherByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher not found"})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher"})
+		return nil, nil, false
+	}
+
+	return authUser, dispatcher, true
+}
+
+func offerErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrOfferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "offer not found"})
+	case errors.Is(err, store.ErrOfferClosed), errors.Is(err, store.ErrOfferExpired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to answer offer"})
+	}
+}
+
+// GetMyOffers godoc
+//
+//	@Summary		Get my job offers
+//	@Description	Get the current dispatcher's job offers, newest first. Pending offers include the package summary
+//	@Tags			Dispatchers
+//	@Accept			json
+//	@Produce		json
+//	@Param			status	query		string	false	"Filter by status (pending, accepted, declined, expired, cancelled)"
+//	@Success		200		{array}		offerResponse
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/dispatchers/me/offers [get]
+//
+//	@Security		BearerAuth
+func (app *application) getMyOffers(c *gin.Context) {
+
+	_, dispatcher, ok := app.currentDispatcher(c)
+	if !ok {
+		return
+	}
+
+	offers, err := app.store.Assignments.GetOffersByDispatcherId(c.Request.Context(), dispatcher.ID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve offers"})
+		return
+	}
+
+	response := []offerResponse{}
+	for _, offer := range *offers {
+		res := newOfferResponse(&offer)
+
+		if offer.Status == "pending" {
+			pack, err := app.store.Packages.GetPackageById(c.Request.Context(), offer.PackageID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve offered package"})
+				return
+			}
+			res.Package = &offerPackageResponse{
+				Origin:      pack.Origin,
+				Destination: pack.Destination,
+				WeightKg:    pack.WeightKg,
+				VehicleType: pack.VehicleType,
+			}
+		}
+
+		response = append(response, res)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AcceptOffer godoc
+//
+//	@Summary		Accept a job offer
+//	@Description	Accept a pending offer. The package is assigned to the current dispatcher and awaits pickup
+//	@Tags			Dispatchers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Offer ID"
+//	@Success		200	{object}	packageResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/dispatchers/me/offers/{id}/accept [post]
+//
+//	@Security		BearerAuth
+func (app *application) acceptOffer(c *gin.Context) {
+
+	authUser, dispatcher, ok := app.currentDispatcher(c)
+	if !ok {
+		return
+	}
+
+	pack, err := app.store.Assignments.AcceptOffer(c.Request.Context(), c.Param("id"), dispatcher.ID, authUser.ID)
+	if err != nil {
+		offerErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPackageResponse(pack))
+}
+
+// DeclineOffer godoc
+//
+//	@Summary		Decline a job offer
+//	@Description	Decline a pending offer. The package is offered to the next candidate dispatcher
+//	@Tags			Dispatchers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Offer ID"
+//	@Success		200	{object}	offerResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/dispatchers/me/offers/{id}/decline [post]
+//
+//	@Security		BearerAuth
+func (app *application) declineOffer(c *gin.Context) {
+
+	_, dispatcher, ok := app.currentDispatcher(c)
+	if !ok {
+		return
+	}
+
+	offer, err := app.store.Assignments.DeclineOffer(c.Request.Context(), c.Param("id"), dispatcher.ID)
+	if err != nil {
+		offerErrorResponse(c, err)
+		return
+	}
+
+	// cascade straight away; if it fails the sweeper picks the package up
+	if _, err := app.offerPackage(c.Request.Context(), offer.PackageID); err != nil && !errors.Is(err, store.ErrNoDispatcherAvailable) {
+		app.logger.Errorw("failed to offer declined package", "package_id", offer.PackageID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, newOfferResponse(offer))
+}
+
+// GetMyOfferStats godoc
+//
+//	@Summary		Get my offer stats
+//	@Description	Get the current dispatcher's offer counts and acceptance rate
+//	@Tags			Dispatchers
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.OfferStats
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/dispatchers/me/offers/stats [get]
+//
+//	@Security		BearerAuth
+func (app *application) getMyOfferStats(c *gin.Context) {
+
+	_, dispatcher, ok := app.currentDispatcher(c)
+	if !ok {
+		return
+	}
+
+	stats, err := app.store.Assignments.GetOfferStats(c.Request.Context(), dispatcher.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve offer stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetDispatcherOfferStats godoc
+//
+//	@Summary		Get dispatcher offer stats
+//	@Description	Get a dispatcher's offer counts and acceptance rate
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Dispatcher ID"
+//	@Success		200	{object}	models.OfferStats
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/dispatchers/{id}/offer-stats [get]
+//
+//	@Security		BearerAuth
+func (app *application) getDispatcherOfferStats(c *gin.Context) {
+
+	stats, err := app.store.Assignments.GetOfferStats(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve offer stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,710 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/pricing"
+	"github.com/puremike/pcourierds/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type createPackageRequest struct {
+	QuoteToken     string `json:"quote_token" binding:"required"`
+	Origin         string `json:"origin" binding:"required"`
+	Destination    string `json:"destination" binding:"required"`
+	RecipientName  string `json:"recipient_name" binding:"required"`
+	RecipientPhone string `json:"recipient_phone" binding:"required"`
+	RecipientEmail string `json:"recipient_email" binding:"omitempty,email"`
+	Description    string `json:"description"`
+}
+
+type updatePackageRequest struct {
+	Origin         *string `json:"origin"`
+	Destination    *string `json:"destination"`
+	RecipientName  *string `json:"recipient_name"`
+	RecipientPhone *string `json:"recipient_phone"`
+	Description    *string `json:"description"`
+}
+
+type cancelPackageRequest struct {
+	Reason string `json:"reason"`
+}
+
+type updatePackageStatusRequest struct {
+	Status              string     `json:"status" binding:"required"`
+	Location            string     `json:"location"`
+	Note                string     `json:"note"`
+	EstimatedDeliveryAt *time.Time `json:"estimated_delivery_at"`
+}
+
+// createPackageResponse carries the recipient's delivery PIN when the booking has no
+// recipient email to send it to. It is only ever returned here; the sender must then
+// pass it on to the recipient, who gives it to the dispatcher.
+type createPackageResponse struct {
+	packageResponse
+	DeliveryPin string `json:"delivery_pin,omitempty"`
+}
+
+type packageStatusEventResponse struct {
+	ID         string `json:"id"`
+	FromStatus string `json:"from_status,omitempty"`
+	ToStatus   string `json:"to_status"`
+	ActorID    string `json:"actor_id,omitempty"`
+	Location   string `json:"location"`
+	Note       string `json:"note"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type packageResponse struct {
+	ID                  string  `json:"id"`
+	UserID              string  `json:"user_id"`
+	DispatcherID        string  `json:"dispatcher_id,omitempty"`
+	TrackingCode        string  `json:"tracking_code"`
+	Origin              string  `json:"origin"`
+	Destination         string  `json:"destination"`
+	RecipientName       string  `json:"recipient_name"`
+	RecipientPhone      string  `json:"recipient_phone"`
+	RecipientEmail      string  `json:"recipient_email,omitempty"`
+	Description         string  `json:"description"`
+	WeightKg            float64 `json:"weight_kg"`
+	LengthCm            float64 `json:"length_cm"`
+	WidthCm             float64 `json:"width_cm"`
+	HeightCm            float64 `json:"height_cm"`
+	VehicleType         string  `json:"vehicle_type"`
+	Price               int64   `json:"price"`
+	Currency            string  `json:"currency"`
+	Status              string  `json:"status"`
+	EstimatedDeliveryAt string  `json:"estimated_delivery_at,omitempty"`
+	CreatedAt           string  `json:"created_at"`
+	UpdatedAt           string  `json:"updated_at"`
+}
+
+func newPackageResponse(pack *models.Package) packageResponse {
+	response := packageResponse{
+		ID:             pack.ID,
+		UserID:         pack.UserID,
+		DispatcherID:   pack.DispatcherID,
+		TrackingCode:   pack.TrackingCode,
+		Origin:         pack.Origin,
+		Destination:    pack.Destination,
+		RecipientName:  pack.RecipientName,
+		RecipientPhone: pack.RecipientPhone,
+		RecipientEmail: pack.RecipientEmail,
+		Description:    pack.Description,
+		WeightKg:       pack.WeightKg,
+		LengthCm:       pack.LengthCm,
+		WidthCm:        pack.WidthCm,
+		HeightCm:       pack.HeightCm,
+		VehicleType:    pack.VehicleType,
+		Price:          pack.Price,
+		Currency:       pack.Currency,
+		Status:         pack.Status,
+		CreatedAt:      pack.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      pack.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if pack.EstimatedDeliveryAt != nil {
+		response.EstimatedDeliveryAt = pack.EstimatedDeliveryAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+func newPackagesResponse(packages *[]models.Package) []packageResponse {
+	response := []packageResponse{}
+	for _, pack := range *packages {
+		response = append(response, newPackageResponse(&pack))
+	}
+	return response
+}
+
+// canAccessPackage reports whether the user is the sender, the assigned dispatcher or an
+// admin. API keys only give access to the packages their owner sent.
+func (app *application) canAccessPackage(c *gin.Context, user *models.User, pack *models.Package) (bool, error) {
+	if isAPIKeyRequest(c) {
+		return pack.UserID == user.ID, nil
+	}
+	if user.Role == "admin" || pack.UserID == user.ID {
+		return true, nil
+	}
+	return app.isAssignedDispatcher(c, user, pack)
+}
+
+// isAssignedDispatcher reports whether the user is the dispatcher assigned to the package.
+func (app *application) isAssignedDispatcher(c *gin.Context, user *models.User, pack *models.Package) (bool, error) {
+	if user.Role != "dispatcher" || pack.DispatcherID == "" {
+		return false, nil
+	}
+
+	dispatcher, err := app.store.Dispatchers.GetDispatcherByUserId(c.Request.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return dispatcher.ID == pack.DispatcherID, nil
+}
+
+// CreatePackage godoc
+//
+//	@Summary		Book a package
+//	@Description	Book a new package delivery at the price of a quote from POST /quotes. The recipient's one-time delivery PIN is emailed to recipient_email. Without a recipient_email the PIN is returned in the response instead, and the sender must pass it on to the recipient
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createPackageRequest	true	"Package payload"
+//	@Success		201		{object}	createPackageResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		422		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/packages [post]
+//
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+func (app *application) createPackage(c *gin.Context) {
+
+	var payload createPackageRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// the package is charged exactly what was quoted, with the quoted parcel details
+	quote, err := app.quoteSigner.Verify(payload.QuoteToken, time.Now())
+	if err != nil {
+		if errors.Is(err, pricing.ErrQuoteExpired) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "quote has expired, request a new one"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quote"})
+		return
+	}
+
+	if quote.UserID != authUser.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quote"})
+		return
+	}
+
+	deliveryPin, err := generateDeliveryPin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create package"})
+		return
+	}
+
+	deliveryPinHash, err := bcrypt.GenerateFromPassword([]byte(deliveryPin), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create package"})
+		return
+	}
+
+	pack := &models.Package{
+		UserID:          authUser.ID,
+		Origin:          payload.Origin,
+		Destination:     payload.Destination,
+		RecipientName:   payload.RecipientName,
+		RecipientPhone:  payload.RecipientPhone,
+		RecipientEmail:  payload.RecipientEmail,
+		Description:     payload.Description,
+		WeightKg:        quote.WeightKg,
+		LengthCm:        quote.LengthCm,
+		WidthCm:         quote.WidthCm,
+		HeightCm:        quote.HeightCm,
+		VehicleType:     quote.VehicleType,
+		OriginLat:       quote.Origin.Lat,
+		OriginLng:       quote.Origin.Lng,
+		DestinationLat:  quote.Destination.Lat,
+		DestinationLng:  quote.Destination.Lng,
+		Price:           quote.Total,
+		Currency:        quote.Currency,
+		DeliveryPinHash: string(deliveryPinHash),
+	}
+
+	createdPackage, err := app.store.Packages.CreatePackage(c.Request.Context(), pack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create package"})
+		return
+	}
+
+	// the booking stands even if no dispatcher can take it yet; the offer sweeper retries
+	if _, err := app.offerPackage(c.Request.Context(), createdPackage.ID); err != nil && !errors.Is(err, store.ErrNoDispatcherAvailable) {
+		app.logger.Errorw("failed to offer package", "package_id", createdPackage.ID, "error", err)
+	}
+
+	response := createPackageResponse{packageResponse: newPackageResponse(createdPackage)}
+	if createdPackage.RecipientEmail != "" {
+		go app.sendDeliveryPin(createdPackage, deliveryPin)
+	} else {
+		response.DeliveryPin = deliveryPin
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetMyPackages godoc
+//
+//	@Summary		Get my packages
+//	@Description	Get all packages booked by the current user
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		packageResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/packages [get]
+//
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+func (app *application) getMyPackages(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	packages, err := app.store.Packages.GetPackagesByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve packages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPackagesResponse(packages))
+}
+
+// GetPackageById godoc
+//
+//	@Summary		Get package
+//	@Description	Get a package by ID. Available to the sender, the assigned dispatcher and admins
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Package ID"
+//	@Success		200	{object}	packageResponse
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/packages/{id} [get]
+//
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+func (app *application) getPackageById(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	allowed, err := app.canAccessPackage(c, authUser, pack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check package access"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPackageResponse(pack))
+}
+
+// UpdatePackage godoc
+//
+//	@Summary		Update package
+//	@Description	Update a package's recipient and description. Only the sender can update, and only before it is picked up. Addresses, parcel size and weight are fixed by the quote; cancel and book again to change them
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Package ID"
+//	@Param			payload	body		updatePackageRequest	true	"Package update payload"
+//	@Success		200		{object}	packageResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		422		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/packages/{id} [patch]
+//
+//	@Security		BearerAuth
+func (app *application) updatePackage(c *gin.Context) {
+
+	var payload updatePackageRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	if pack.UserID != authUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	if pack.Status != store.PackageStatusCreated {
+		c.JSON(http.StatusConflict, gin.H{"error": "package can no longer be updated"})
+		return
+	}
+
+	// the addresses were priced by the quote the package was booked with
+	if (payload.Origin != nil && *payload.Origin != pack.Origin) || (payload.Destination != nil && *payload.Destination != pack.Destination) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "origin and destination are fixed by the quote, cancel and book again to change them"})
+		return
+	}
+
+	if payload.RecipientName != nil && *payload.RecipientName != "" {
+		pack.RecipientName = *payload.RecipientName
+	}
+	if payload.RecipientPhone != nil && *payload.RecipientPhone != "" {
+		pack.RecipientPhone = *payload.RecipientPhone
+	}
+	if payload.Description != nil {
+		pack.Description = *payload.Description
+	}
+
+	updatedPackage, err := app.store.Packages.UpdatePackage(c.Request.Context(), pack, pack.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrPackageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		case errors.Is(err, store.ErrPackageNotEditable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update package"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newPackageResponse(updatedPackage))
+}
+
+// CancelPackage godoc
+//
+//	@Summary		Cancel package
+//	@Description	Cancel a package before it is picked up. Available to the sender and admins
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Package ID"
+//	@Param			payload	body		cancelPackageRequest	false	"Cancellation reason"
+//	@Success		200		{object}	map[string]string		"package cancelled"
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/packages/{id}/cancel [patch]
+//
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+func (app *application) cancelPackage(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	if pack.UserID != authUser.ID && (authUser.Role != "admin" || isAPIKeyRequest(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var payload cancelPackageRequest
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := app.store.Packages.CancelPackage(c.Request.Context(), pack.ID, authUser.ID, payload.Reason); err != nil {
+		var transitionErr *store.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": "package can no longer be cancelled"})
+			return
+		}
+		if errors.Is(err, store.ErrPackageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel package"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "package cancelled"})
+}
+
+// GetDispatcherPackages godoc
+//
+//	@Summary		Get assigned packages
+//	@Description	Get all packages assigned to the current dispatcher
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		packageResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/dispatchers/me/packages [get]
+//
+//	@Security		BearerAuth
+func (app *application) getDispatcherPackages(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dispatcher, err := app.store.Dispatchers.GetDispatcherByUserId(c.Request.Context(), authUser.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrDispatcherNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispatcher not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve dispatcher"})
+		return
+	}
+
+	packages, err := app.store.Packages.GetPackagesByDispatcherId(c.Request.Context(), dispatcher.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve packages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPackagesResponse(packages))
+}
+
+// UpdatePackageStatus godoc
+//
+//	@Summary		Update package status
+//	@Description	Move a package along its delivery lifecycle. Available to the assigned dispatcher and admins. Deliveries are confirmed through POST /packages/{id}/deliver
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"Package ID"
+//	@Param			payload	body		updatePackageStatusRequest	true	"Status payload"
+//	@Success		200		{object}	packageResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		422		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/packages/{id}/status [patch]
+//
+//	@Security		BearerAuth
+func (app *application) updatePackageStatus(c *gin.Context) {
+
+	var payload updatePackageStatusRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !store.IsValidPackageStatus(payload.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid package status"})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	// senders go through the cancel endpoint; only the courier side drives the lifecycle
+	if authUser.Role != "admin" {
+		allowed, err := app.isAssignedDispatcher(c, authUser, pack)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check package access"})
+			return
+		}
+		if !allowed || payload.Status == store.PackageStatusCancelled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+	}
+
+	event := &models.PackageStatusEvent{
+		ToStatus: payload.Status,
+		ActorID:  authUser.ID,
+		Location: payload.Location,
+		Note:     payload.Note,
+	}
+
+	updatedPackage, err := app.store.Packages.UpdatePackageStatus(c.Request.Context(), pack.ID, event)
+	if err != nil {
+		var transitionErr *store.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": transitionErr.Error()})
+			return
+		}
+		if errors.Is(err, store.ErrProofOfDeliveryRequired) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "deliveries must be confirmed through POST /packages/:id/deliver"})
+			return
+		}
+		if errors.Is(err, store.ErrPackageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update package status"})
+		return
+	}
+
+	if payload.EstimatedDeliveryAt != nil {
+		if err := app.store.Packages.UpdateEstimatedDelivery(c.Request.Context(), pack.ID, *payload.EstimatedDeliveryAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update estimated delivery"})
+			return
+		}
+		updatedPackage.EstimatedDeliveryAt = payload.EstimatedDeliveryAt
+	}
+
+	c.JSON(http.StatusOK, newPackageResponse(updatedPackage))
+}
+
+// GetPackageHistory godoc
+//
+//	@Summary		Get package status history
+//	@Description	Get every status change of a package. Available to the sender, the assigned dispatcher and admins
+//	@Tags			Packages
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Package ID"
+//	@Success		200	{array}		packageStatusEventResponse
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/packages/{id}/history [get]
+//
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+func (app *application) getPackageHistory(c *gin.Context) {
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pack, err := app.getPackageFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	allowed, err := app.canAccessPackage(c, authUser, pack)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check package access"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	events, err := app.store.Packages.GetPackageStatusEvents(c.Request.Context(), pack.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve package history"})
+		return
+	}
+
+	response := []packageStatusEventResponse{}
+	for _, event := range *events {
+		response = append(response, packageStatusEventResponse{
+			ID:         event.ID,
+			FromStatus: event.FromStatus,
+			ToStatus:   event.ToStatus,
+			ActorID:    event.ActorID,
+			Location:   event.Location,
+			Note:       event.Note,
+			CreatedAt:  event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AssignPackage godoc
+//
+//	@Summary		Offer a package to a dispatcher
+//	@Description	Run dispatcher selection again for a package that is still waiting for one and offer it to the best candidate
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Package ID"
+//	@Success		200	{object}	offerResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		503	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/packages/{id}/assign [post]
+//
+//	@Security		BearerAuth
+func (app *application) assignPackage(c *gin.Context) {
+
+	offer, err := app.offerPackage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		var transitionErr *store.InvalidTransitionError
+		switch {
+		case errors.Is(err, store.ErrPackageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		case errors.Is(err, store.ErrPackageAlreadyAssigned), errors.Is(err, store.ErrOfferPending), errors.As(err, &transitionErr):
+			c.JSON(http.StatusConflict, gin.H{"error": "package is not awaiting a dispatcher"})
+		case errors.Is(err, store.ErrNoDispatcherAvailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no dispatcher available"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to offer package"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newOfferResponse(offer))
+}
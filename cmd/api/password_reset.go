@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/auth"
+	"github.com/puremike/pcourierds/internal/mailer"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mailSendTimeout bounds sending an email in the background.
+const mailSendTimeout = 30 * time.Second
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,passwd"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,passwd"`
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Request a password reset
+//	@Description	Email a single-use password reset link if an account exists for the address. The response is the same whether or not it does
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		forgotPasswordRequest	true	"Account email"
+//	@Success		202		{object}	map[string]string
+//	@Failure		400		{object}	error
+//	@Failure		429		{object}	error
+//	@Router			/auth/forgot-password [post]
+func (app *application) forgotPassword(c *gin.Context) {
+	var payload forgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the lookup and email happen after responding so the response time does not
+	// reveal whether the account exists
+	email := strings.TrimSpace(payload.Email)
+	if ok, _ := app.rateLimits.forgotPasswordEmail.Allow(strings.ToLower(email)); ok {
+		go app.sendPasswordReset(email)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if an account exists for this email, a password reset link has been sent"})
+}
+
+func (app *application) sendPasswordReset(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	user, err := app.store.Users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, store.ErrUserNotFound) {
+			app.logger.Errorw("failed to retrieve user for password reset", "error", err)
+		}
+		return
+	}
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		app.logger.Errorw("failed to generate password reset token", "error", err)
+		return
+	}
+
+	resetToken := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   store.UserTokenPurposePasswordReset,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(app.config.passwordResetConfig.tokenTTL),
+	}
+
+	if err := app.store.UserTokens.CreateUserToken(ctx, resetToken); err != nil {
+		app.logger.Errorw("failed to save password reset token", "user_id", user.ID, "error", err)
+		return
+	}
+
+	link := app.config.frontendURL + "/reset-password?token=" + url.QueryEscape(token)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			user.Username, app.config.passwordResetConfig.tokenTTL, link),
+	}
+
+	if err := app.mailer.Send(ctx, msg); err != nil {
+		app.logger.Errorw("failed to send password reset email", "user_id", user.ID, "error", err)
+	}
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset password
+//	@Description	Set a new password with a token from a password reset email. Every session of the user is signed out
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		resetPasswordRequest	true	"Reset token and new password"
+//	@Success		200		{object}	string					"password reset"
+//	@Failure		400		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/reset-password [post]
+func (app *application) resetPassword(c *gin.Context) {
+	var payload resetPasswordRequest
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if payload.NewPassword != payload.ConfirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passwords do not match"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	err = app.store.WithTx(c.Request.Context(), func(tx *store.Storage) error {
+		token, err := tx.UserTokens.ConsumeUserToken(c.Request.Context(), store.UserTokenPurposePasswordReset, auth.HashOpaqueToken(payload.Token))
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Users.UpdatePassword(c.Request.Context(), &models.User{Password: string(hashedPassword)}, token.UserID); err != nil {
+			return fmt.Errorf("update password: %w", err)
+		}
+
+		if err := tx.RevokedTokens.RevokeAllUserTokens(c.Request.Context(), token.UserID); err != nil {
+			return fmt.Errorf("revoke tokens: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+			return
+		}
+		app.logger.Errorw("failed to reset password", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, "password reset successfully")
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/pricing"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+type pointRequest struct {
+	Lat *float64 `json:"lat" binding:"required,gte=-90,lte=90"`
+	Lng *float64 `json:"lng" binding:"required,gte=-180,lte=180"`
+}
+
+func (p pointRequest) point() geo.Point {
+	return geo.Point{Lat: *p.Lat, Lng: *p.Lng}
+}
+
+type quoteRequest struct {
+	Origin      pointRequest `json:"origin" binding:"required"`
+	Destination pointRequest `json:"destination" binding:"required"`
+	WeightKg    float64      `json:"weight_kg" binding:"required,gt=0"`
+	LengthCm    float64      `json:"length_cm" binding:"gte=0"`
+	WidthCm     float64      `json:"width_cm" binding:"gte=0"`
+	HeightCm    float64      `json:"height_cm" binding:"gte=0"`
+	VehicleType string       `json:"vehicle_type" binding:"required"`
+}
+
+type quoteResponse struct {
+	QuoteToken         string             `json:"quote_token"`
+	VehicleType        string             `json:"vehicle_type"`
+	DistanceKm         float64            `json:"distance_km"`
+	EstimatedMinutes   int                `json:"estimated_minutes"`
+	ChargeableWeightKg float64            `json:"chargeable_weight_kg"`
+	Currency           string             `json:"currency"`
+	Items              []pricing.LineItem `json:"items"`
+	Total              int64              `json:"total"`
+	ExpiresAt          string             `json:"expires_at"`
+}
+
+type pricingRateRequest struct {
+	Currency          string `json:"currency" binding:"required,len=3"`
+	BaseFare          int64  `json:"base_fare" binding:"gte=0"`
+	PerKm             int64  `json:"per_km" binding:"gte=0"`
+	PerKg             int64  `json:"per_kg" binding:"gte=0"`
+	MinFare           int64  `json:"min_fare" binding:"gte=0"`
+	VolumetricDivisor int    `json:"volumetric_divisor" binding:"gte=0"`
+}
+
+type weightTierRequest struct {
+	MinWeightKg float64 `json:"min_weight_kg" binding:"gte=0"`
+	MaxWeightKg float64 `json:"max_weight_kg" binding:"required,gtfield=MinWeightKg"`
+	Surcharge   int64   `json:"surcharge" binding:"gte=0"`
+}
+
+type weightTiersRequest struct {
+	Tiers []weightTierRequest `json:"tiers" binding:"required,min=1,dive"`
+}
+
+type pricingRateResponse struct {
+	models.PricingRate
+	Tiers []models.WeightTier `json:"tiers"`
+}
+
+// CreateQuote godoc
+//
+//	@Summary		Get a delivery quote
+//	@Description	Price a delivery before booking. The returned quote_token must be sent to POST /packages before it expires
+//	@Tags			Pricing
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		quoteRequest	true	"Quote payload"
+//	@Success		200		{object}	quoteResponse
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		422		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/quotes [post]
+//
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+func (app *application) createQuote(c *gin.Context) {
+
+	var payload quoteRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authUser, err := app.getUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	vehicle, ok := app.activeVehicleType(c, payload.VehicleType)
+	if !ok {
+		return
+	}
+
+	rate, err := app.store.Pricing.GetRate(c.Request.Context(), payload.VehicleType)
+	if err != nil {
+		if errors.Is(err, store.ErrPricingRateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pricing available for this vehicle type"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve pricing rate"})
+		return
+	}
+
+	tiers, err := app.store.Pricing.GetWeightTiers(c.Request.Context(), payload.VehicleType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve weight tiers"})
+		return
+	}
+
+	req := pricing.Request{
+		Origin:      payload.Origin.point(),
+		Destination: payload.Destination.point(),
+		WeightKg:    payload.WeightKg,
+		LengthCm:    payload.LengthCm,
+		WidthCm:     payload.WidthCm,
+		HeightCm:    payload.HeightCm,
+		VehicleType: payload.VehicleType,
+	}
+
+	quote, err := pricing.Calculate(req, vehicle, rate, *tiers, time.Now(), app.config.quoteConfig.ttl)
+	if err != nil {
+		if errors.Is(err, pricing.ErrWeightNotServed) || errors.Is(err, pricing.ErrInvalidRequest) || errors.Is(err, pricing.ErrExceedsVehicleCapacity) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate quote"})
+		return
+	}
+
+	quote.UserID = authUser.ID
+
+	token, err := app.quoteSigner.Sign(quote)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign quote"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quoteResponse{
+		QuoteToken:         token,
+		VehicleType:        quote.VehicleType,
+		DistanceKm:         quote.DistanceKm,
+		EstimatedMinutes:   quote.EstimatedMinutes,
+		ChargeableWeightKg: quote.ChargeableWeightKg,
+		Currency:           quote.Currency,
+		Items:              quote.Items,
+		Total:              quote.Total,
+		ExpiresAt:          quote.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// GetPricingRates godoc
+//
+//	@Summary		Get pricing rates
+//	@Description	Get the pricing rate and weight tiers of every vehicle type
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		pricingRateResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/pricing/rates [get]
+//
+//	@Security		BearerAuth
+func (app *application) getPricingRates(c *gin.Context) {
+
+	rates, err := app.store.Pricing.GetAllRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve pricing rates"})
+		return
+	}
+
+	response := []pricingRateResponse{}
+	for _, rate := range *rates {
+		tiers, err := app.store.Pricing.GetWeightTiers(c.Request.Context(), rate.VehicleType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve weight tiers"})
+			return
+		}
+		response = append(response, pricingRateResponse{PricingRate: rate, Tiers: *tiers})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpsertPricingRate godoc
+//
+//	@Summary		Set pricing rate
+//	@Description	Create or update the pricing rate of a vehicle type. Amounts are in the currency's minor unit
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			vehicleType	path		string				true	"Vehicle type"
+//	@Param			payload		body		pricingRateRequest	true	"Pricing rate payload"
+//	@Success		200			{object}	models.PricingRate
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/admin/pricing/rates/{vehicleType} [put]
+//
+//	@Security		BearerAuth
+func (app *application) upsertPricingRate(c *gin.Context) {
+
+	var payload pricingRateRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vehicleType := c.Param("vehicleType")
+	if _, err := app.store.VehicleTypes.GetVehicleType(c.Request.Context(), vehicleType); err != nil {
+		if errors.Is(err, store.ErrVehicleTypeNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle type"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve vehicle type"})
+		return
+	}
+
+	rate := &models.PricingRate{
+		VehicleType:       vehicleType,
+		Currency:          payload.Currency,
+		BaseFare:          payload.BaseFare,
+		PerKm:             payload.PerKm,
+		PerKg:             payload.PerKg,
+		MinFare:           payload.MinFare,
+		VolumetricDivisor: payload.VolumetricDivisor,
+	}
+
+	savedRate, err := app.store.Pricing.UpsertRate(c.Request.Context(), rate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save pricing rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, savedRate)
+}
+
+// ReplaceWeightTiers godoc
+//
+//	@Summary		Set weight tiers
+//	@Description	Replace every weight tier of a vehicle type. Tiers cover (min_weight_kg, max_weight_kg] and must not overlap
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			vehicleType	path		string				true	"Vehicle type"
+//	@Param			payload		body		weightTiersRequest	true	"Weight tiers payload"
+//	@Success		200			{array}		models.WeightTier
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/admin/pricing/rates/{vehicleType}/tiers [put]
+//
+//	@Security		BearerAuth
+func (app *application) replaceWeightTiers(c *gin.Context) {
+
+	var payload weightTiersRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tiers := []models.WeightTier{}
+	for _, t := range payload.Tiers {
+		tier := models.WeightTier{MinWeightKg: t.MinWeightKg, MaxWeightKg: t.MaxWeightKg, Surcharge: t.Surcharge}
+		for _, other := range tiers {
+			if tier.MinWeightKg < other.MaxWeightKg && other.MinWeightKg < tier.MaxWeightKg {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "weight tiers must not overlap"})
+				return
+			}
+		}
+		tiers = append(tiers, tier)
+	}
+
+	saved, err := app.store.Pricing.ReplaceWeightTiers(c.Request.Context(), c.Param("vehicleType"), tiers)
+	if err != nil {
+		if errors.Is(err, store.ErrPricingRateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "pricing rate not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save weight tiers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/store"
+	"github.com/puremike/pcourierds/internal/tracking"
+)
+
+type trackingEventResponse struct {
+	Status    string `json:"status"`
+	Location  string `json:"location,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+type trackingResponse struct {
+	TrackingCode        string                  `json:"tracking_code"`
+	Status              string                  `json:"status"`
+	EstimatedDeliveryAt string                  `json:"estimated_delivery_at,omitempty"`
+	Timeline            []trackingEventResponse `json:"timeline"`
+}
+
+// coarseLocation keeps only the trailing administrative parts of a comma-separated
+// location so street addresses are never exposed publicly: the last two parts
+// (typically city and region) when there are more than two, only the last one when
+// there are two, and nothing when the location cannot be split at all.
+func coarseLocation(location string) string {
+	parts := strings.Split(location, ",")
+	switch {
+	case len(parts) < 2:
+		return ""
+	case len(parts) == 2:
+		parts = parts[1:]
+	default:
+		parts = parts[len(parts)-2:]
+	}
+
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return strings.Trim(strings.Join(parts, ", "), ", ")
+}
+
+// TrackPackage godoc
+//
+//	@Summary		Track a package
+//	@Description	Public tracking timeline for a package. Actors, notes and exact addresses are redacted
+//	@Tags			Tracking
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path		string	true	"Tracking code, e.g. PCD-7K3M-Q9XA"
+//	@Success		200		{object}	trackingResponse
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/track/{code} [get]
+func (app *application) trackPackage(c *gin.Context) {
+
+	code := tracking.Normalize(c.Param("code"))
+	if !tracking.Valid(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tracking code"})
+		return
+	}
+
+	pack, err := app.store.Packages.GetPackageByTrackingCode(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, store.ErrPackageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve package"})
+		return
+	}
+
+	events, err := app.store.Packages.GetPackageStatusEvents(c.Request.Context(), pack.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve package history"})
+		return
+	}
+
+	response := trackingResponse{
+		TrackingCode: pack.TrackingCode,
+		Status:       pack.Status,
+		Timeline:     []trackingEventResponse{},
+	}
+
+	if pack.EstimatedDeliveryAt != nil && !store.IsTerminalPackageStatus(pack.Status) {
+		response.EstimatedDeliveryAt = pack.EstimatedDeliveryAt.Format(time.RFC3339)
+	}
+
+	for _, event := range *events {
+		response.Timeline = append(response.Timeline, trackingEventResponse{
+			Status:    event.ToStatus,
+			Location:  coarseLocation(event.Location),
+			Timestamp: event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
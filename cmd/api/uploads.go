@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// sniffContentType detects the content type of r from its first bytes and returns a
+// reader that still yields the whole content.
+func sniffContentType(r io.Reader) (string, io.Reader, error) {
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(r, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+
+	return http.DetectContentType(sniff[:n]), io.MultiReader(bytes.NewReader(sniff[:n]), r), nil
+}
+
+// randomKeySuffix returns a random hex string that keeps blob keys unique.
+func randomKeySuffix() (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(suffix), nil
+}
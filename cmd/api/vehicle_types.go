@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/store"
+)
+
+var vehicleTypeCode = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type vehicleTypeRequest struct {
+	Name              string   `json:"name" binding:"required"`
+	MaxWeightKg       float64  `json:"max_weight_kg" binding:"required,gt=0"`
+	MaxVolumeL        float64  `json:"max_volume_l" binding:"required,gt=0"`
+	AvgSpeedKmh       float64  `json:"avg_speed_kmh" binding:"required,gt=0"`
+	PricingMultiplier *float64 `json:"pricing_multiplier" binding:"omitempty,gt=0"`
+	IsActive          *bool    `json:"is_active"`
+}
+
+// activeVehicleType looks up a vehicle type that may be used for new applications and
+// bookings, responding with 400 when it is unknown or retired.
+func (app *application) activeVehicleType(c *gin.Context, code string) (*models.VehicleType, bool) {
+	vehicle, err := app.store.VehicleTypes.GetVehicleType(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, store.ErrVehicleTypeNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown vehicle type"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve vehicle type"})
+		return nil, false
+	}
+
+	if !vehicle.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vehicle type is no longer available"})
+		return nil, false
+	}
+
+	return vehicle, true
+}
+
+// GetVehicleTypes godoc
+//
+//	@Summary		Get vehicle types
+//	@Description	Get the vehicle types available for applications and bookings, with what each can carry
+//	@Tags			Pricing
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.VehicleType
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/vehicle-types [get]
+//
+//	@Security		BearerAuth
+func (app *application) getVehicleTypes(c *gin.Context) {
+
+	vehicles, err := app.store.VehicleTypes.GetAllVehicleTypes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve vehicle types"})
+		return
+	}
+
+	active := []models.VehicleType{}
+	for _, vehicle := range *vehicles {
+		if vehicle.IsActive {
+			active = append(active, vehicle)
+		}
+	}
+
+	c.JSON(http.StatusOK, active)
+}
+
+// GetAllVehicleTypes godoc
+//
+//	@Summary		Get all vehicle types
+//	@Description	Get every vehicle type, including retired ones
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.VehicleType
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/admin/vehicle-types [get]
+//
+//	@Security		BearerAuth
+func (app *application) getAllVehicleTypes(c *gin.Context) {
+
+	vehicles, err := app.store.VehicleTypes.GetAllVehicleTypes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve vehicle types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vehicles)
+}
+
+// UpsertVehicleType godoc
+//
+//	@Summary		Create or update a vehicle type
+//	@Description	Set the capacity, average speed and pricing multiplier of a vehicle type. New types also need a pricing rate before they can be quoted
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path		string				true	"Vehicle type code"
+//	@Param			payload	body		vehicleTypeRequest	true	"Vehicle type payload"
+//	@Success		200		{object}	models.VehicleType
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/admin/vehicle-types/{code} [put]
+//
+//	@Security		BearerAuth
+func (app *application) upsertVehicleType(c *gin.Context) {
+
+	var payload vehicleTypeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := strings.ToLower(c.Param("code"))
+	if !vehicleTypeCode.MatchString(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vehicle type code must be lowercase letters, digits and underscores"})
+		return
+	}
+
+	vehicle := &models.VehicleType{
+		Code:              code,
+		Name:              strings.TrimSpace(payload.Name),
+		MaxWeightKg:       payload.MaxWeightKg,
+		MaxVolumeL:        payload.MaxVolumeL,
+		AvgSpeedKmh:       payload.AvgSpeedKmh,
+		PricingMultiplier: 1,
+		IsActive:          true,
+	}
+	if payload.PricingMultiplier != nil {
+		vehicle.PricingMultiplier = *payload.PricingMultiplier
+	}
+	if payload.IsActive != nil {
+		vehicle.IsActive = *payload.IsActive
+	}
+
+	savedVehicle, err := app.store.VehicleTypes.UpsertVehicleType(c.Request.Context(), vehicle)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save vehicle type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, savedVehicle)
+}
+
+// DeleteVehicleType godoc
+//
+//	@Summary		Delete a vehicle type
+//	@Description	Delete a vehicle type and its pricing. Types used by an application, dispatcher or package cannot be deleted; set is_active to false instead
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path		string	true	"Vehicle type code"
+//	@Success		200		{object}	string
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/admin/vehicle-types/{code} [delete]
+//
+//	@Security		BearerAuth
+func (app *application) deleteVehicleType(c *gin.Context) {
+
+	if err := app.store.VehicleTypes.DeleteVehicleType(c.Request.Context(), strings.ToLower(c.Param("code"))); err != nil {
+		switch {
+		case errors.Is(err, store.ErrVehicleTypeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "vehicle type not found"})
+		case errors.Is(err, store.ErrVehicleTypeInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": "vehicle type is still in use; deactivate it instead"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete vehicle type"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, "vehicle type deleted successfully")
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// apiKeyTag starts every API key, so leaked keys are easy to recognize.
+const apiKeyTag = "pcd_"
+
+// NewAPIKey returns a random API key, its visible prefix and the hash to store for it.
+// Keys look like pcd_<prefix id>_<secret>; the prefix identifies a key in listings
+// without revealing it.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	id := make([]byte, 5)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = apiKeyTag + strings.ToLower(totpEncoding.EncodeToString(id))
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, prefix, HashOpaqueToken(key), nil
+}
@@ -5,4 +5,18 @@ import "github.com/golang-jwt/jwt/v5"
 type Authenticator interface {
 	GenerateToken(claims jwt.Claims) (string, error)
 	ValidateToken(token string) (*jwt.Token, error)
+	// ValidateTokenForAudience validates a token issued for another audience than
+	// access tokens, such as a login challenge.
+	ValidateTokenForAudience(token, aud string) (*jwt.Token, error)
+}
+
+// KeyPublisher is implemented by authenticators whose tokens other services can verify
+// with published public keys.
+type KeyPublisher interface {
+	JWKS() JWKS
+}
+
+// Reloader is implemented by authenticators that can reload their keys while running.
+type Reloader interface {
+	Reload() error
 }
@@ -25,10 +25,14 @@ func (j *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
 }
 
 func (j *JWTAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
+	return j.ValidateTokenForAudience(token, j.aud)
+}
+
+func (j *JWTAuthenticator) ValidateTokenForAudience(token, aud string) (*jwt.Token, error) {
 	return jwt.Parse(token, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
 		return []byte(j.secret), nil
-	}, jwt.WithAudience(j.aud), jwt.WithIssuer(j.iss), jwt.WithExpirationRequired(), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	}, jwt.WithAudience(aud), jwt.WithIssuer(j.iss), jwt.WithExpirationRequired(), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 }
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing keys.
+const minRSABits = 2048
+
+// SigningKeyIDFile is the file in the key directory that names the signing key. It
+// takes precedence over the signing key id given at startup and is read on every Reload.
+const SigningKeyIDFile = "signing_key_id"
+
+// KeySetAuthenticator signs tokens with an RS256 or EdDSA private key and verifies
+// them against every key in a directory of PEM files, named <kid>.pem. The kid of the
+// signing key is set in the token header, so tokens keep verifying while keys rotate.
+//
+// Keys are rotated without downtime by adding the new key to the directory and calling
+// Reload on every instance, then writing its kid to SigningKeyIDFile and calling Reload
+// again once all of them verify it, and removing the old key after the tokens it signed
+// have expired. Public key files can be used for keys that should only be verified.
+type KeySetAuthenticator struct {
+	dir, signingKeyID, iss, aud string
+	keys                        atomic.Pointer[keySet]
+}
+
+type keySet struct {
+	signing *signingKey
+	byID    map[string]*signingKey
+}
+
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer // nil for keys that are only verified
+	public  crypto.PublicKey
+}
+
+// NewKeySetAuthenticator loads the keys in dir. signingKeyID selects the private key
+// tokens are signed with; it may be empty when dir holds exactly one private key.
+func NewKeySetAuthenticator(dir, signingKeyID, iss, aud string) (*KeySetAuthenticator, error) {
+	a := &KeySetAuthenticator{dir: dir, signingKeyID: signingKeyID, iss: iss, aud: aud}
+
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Reload reads the key directory, including SigningKeyIDFile, again. On error the keys
+// loaded before stay in use.
+func (a *KeySetAuthenticator) Reload() error {
+	signingKeyID := a.signingKeyID
+
+	data, err := os.ReadFile(filepath.Join(a.dir, SigningKeyIDFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if id := strings.TrimSpace(string(data)); id != "" {
+		signingKeyID = id
+	}
+
+	set, err := loadKeySet(a.dir, signingKeyID)
+	if err != nil {
+		return err
+	}
+
+	a.keys.Store(set)
+	return nil
+}
+
+func (a *KeySetAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
+	key := a.keys.Load().signing
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	return token.SignedString(key.private)
+}
+
+func (a *KeySetAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
+	return a.ValidateTokenForAudience(token, a.aud)
+}
+
+func (a *KeySetAuthenticator) ValidateTokenForAudience(token, aud string) (*jwt.Token, error) {
+	set := a.keys.Load()
+
+	return jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key, ok := set.byID[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return key.public, nil
+	}, jwt.WithAudience(aud), jwt.WithIssuer(a.iss), jwt.WithExpirationRequired(), jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+}
+
+// JWKS returns the public half of every loaded key.
+func (a *KeySetAuthenticator) JWKS() JWKS {
+	set := a.keys.Load()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(set.byID))}
+	for _, key := range set.byID {
+		jwks.Keys = append(jwks.Keys, key.jwk())
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	return jwks
+}
+
+func loadKeySet(dir, signingKeyID string) (*keySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := &keySet{byID: make(map[string]*signingKey, len(paths))}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseKeyPEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		set.byID[key.id] = key
+
+		if key.private != nil && (key.id == signingKeyID || signingKeyID == "") {
+			if set.signing != nil && signingKeyID == "" {
+				return nil, fmt.Errorf("%s holds more than one private key, set the signing key id", dir)
+			}
+			set.signing = key
+		}
+	}
+
+	if set.signing == nil {
+		if signingKeyID != "" {
+			return nil, fmt.Errorf("no private key %q in %s", signingKeyID, dir)
+		}
+		return nil, fmt.Errorf("no private key in %s", dir)
+	}
+
+	return set, nil
+}
+
+func parseKeyPEM(id string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var (
+		parsed any
+		err    error
+	)
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: id}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+
+	if pub, ok := key.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+	}
+
+	return key, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKS is a JSON Web Key Set, as published at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *signingKey) jwk() JWK {
+	jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "pcourierds"
+	testAudience = "pcourierds-api"
+)
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writePrivateKey writes key to dir/<id>.pem in PKCS #8 form.
+func writePrivateKey(t *testing.T, dir, id string, key crypto.Signer) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, id, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, id string, key crypto.PublicKey) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, id, "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, dir, id, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testClaims(aud string, expiresAt time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "user-1",
+		Issuer:    testIssuer,
+		Audience:  jwt.ClaimStrings{aud},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+}
+
+func TestNewKeySetAuthenticatorSigningKey(t *testing.T) {
+	edKey := newEd25519Key(t)
+	rsaKey := newRSAKey(t, 2048)
+
+	tests := []struct {
+		name         string
+		setup        func(t *testing.T, dir string)
+		signingKeyID string
+		wantKid      string
+		wantErr      bool
+	}{
+		{
+			name:    "single private key",
+			setup:   func(t *testing.T, dir string) { writePrivateKey(t, dir, "a", edKey) },
+			wantKid: "a",
+		},
+		{
+			name: "public keys are only verified",
+			setup: func(t *testing.T, dir string) {
+				writePrivateKey(t, dir, "a", edKey)
+				writePublicKey(t, dir, "b", rsaKey.Public())
+			},
+			wantKid: "a",
+		},
+		{
+			name: "signing key id picks the key",
+			setup: func(t *testing.T, dir string) {
+				writePrivateKey(t, dir, "a", edKey)
+				writePrivateKey(t, dir, "b", rsaKey)
+			},
+			signingKeyID: "b",
+			wantKid:      "b",
+		},
+		{
+			name: "signing key id file overrides the signing key id",
+			setup: func(t *testing.T, dir string) {
+				writePrivateKey(t, dir, "a", edKey)
+				writePrivateKey(t, dir, "b", rsaKey)
+				if err := os.WriteFile(filepath.Join(dir, SigningKeyIDFile), []byte("b\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			signingKeyID: "a",
+			wantKid:      "b",
+		},
+		{
+			name: "several private keys need a signing key id",
+			setup: func(t *testing.T, dir string) {
+				writePrivateKey(t, dir, "a", edKey)
+				writePrivateKey(t, dir, "b", rsaKey)
+			},
+			wantErr: true,
+		},
+		{
+			name:         "unknown signing key id",
+			setup:        func(t *testing.T, dir string) { writePrivateKey(t, dir, "a", edKey) },
+			signingKeyID: "b",
+			wantErr:      true,
+		},
+		{
+			name:    "no private key",
+			setup:   func(t *testing.T, dir string) { writePublicKey(t, dir, "a", edKey.Public()) },
+			wantErr: true,
+		},
+		{
+			name:    "empty directory",
+			setup:   func(t *testing.T, dir string) {},
+			wantErr: true,
+		},
+		{
+			name:    "RSA key too small",
+			setup:   func(t *testing.T, dir string) { writePrivateKey(t, dir, "a", newRSAKey(t, 1024)) },
+			wantErr: true,
+		},
+		{
+			name: "not PEM",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "a.pem"), []byte("not a key"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(t, dir)
+
+			a, err := NewKeySetAuthenticator(dir, tt.signingKeyID, testIssuer, testAudience)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewKeySetAuthenticator() returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := a.GenerateToken(testClaims(testAudience, time.Now().Add(time.Minute)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := a.ValidateToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kid := parsed.Header["kid"]; kid != tt.wantKid {
+				t.Errorf("token kid = %v, want %q", kid, tt.wantKid)
+			}
+		})
+	}
+}
+
+func TestKeySetAuthenticatorValidateToken(t *testing.T) {
+	dir := t.TempDir()
+	key := newEd25519Key(t)
+	writePrivateKey(t, dir, "a", key)
+
+	a, err := NewKeySetAuthenticator(dir, "", testIssuer, testAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, signingKey any, claims jwt.Claims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+
+		signed, err := token.SignedString(signingKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	hour := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		aud     string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: sign(jwt.SigningMethodEdDSA, "a", key, testClaims(testAudience, hour)),
+			aud:   testAudience,
+		},
+		{
+			name:  "own audience",
+			token: sign(jwt.SigningMethodEdDSA, "a", key, testClaims(testAudience+"/mfa", hour)),
+			aud:   testAudience + "/mfa",
+		},
+		{
+			name:    "other audience",
+			token:   sign(jwt.SigningMethodEdDSA, "a", key, testClaims(testAudience+"/mfa", hour)),
+			aud:     testAudience,
+			wantErr: true,
+		},
+		{
+			name:    "other issuer",
+			token:   sign(jwt.SigningMethodEdDSA, "a", key, jwt.RegisteredClaims{Issuer: "someone", Audience: jwt.ClaimStrings{testAudience}, ExpiresAt: jwt.NewNumericDate(hour)}),
+			aud:     testAudience,
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   sign(jwt.SigningMethodEdDSA, "a", key, testClaims(testAudience, time.Now().Add(-time.Minute))),
+			aud:     testAudience,
+			wantErr: true,
+		},
+		{
+			name:    "no expiry",
+			token:   sign(jwt.SigningMethodEdDSA, "a", key, jwt.RegisteredClaims{Issuer: testIssuer, Audience: jwt.ClaimStrings{testAudience}}),
+			aud:     testAudience,
+			wantErr: true,
+		},
+		{
+			name:    "unknown kid",
+			token:   sign(jwt.SigningMethodEdDSA, "b", key, testClaims(testAudience, hour)),
+			aud:     testAudience,
+			wantErr: true,
+		},
+		{
+			name:    "signed by another key",
+			token:   sign(jwt.SigningMethodEdDSA, "a", newEd25519Key(t), testClaims(testAudience, hour)),
+			aud:     testAudience,
+			wantErr: true,
+		},
+		{
+			name:    "HMAC with the public key",
+			token:   sign(jwt.SigningMethodHS256, "a", []byte(key.Public().(ed25519.PublicKey)), testClaims(testAudience, hour)),
+			aud:     testAudience,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := a.ValidateTokenForAudience(tt.token, tt.aud)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTokenForAudience() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetAuthenticatorRotation(t *testing.T) {
+	dir := t.TempDir()
+	writePrivateKey(t, dir, "a", newEd25519Key(t))
+
+	a, err := NewKeySetAuthenticator(dir, "a", testIssuer, testAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, err := a.GenerateToken(testClaims(testAudience, time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePrivateKey(t, dir, "b", newRSAKey(t, 2048))
+	if err := os.WriteFile(filepath.Join(dir, SigningKeyIDFile), []byte("b"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, err := a.GenerateToken(testClaims(testAudience, time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := a.ValidateToken(token); err != nil {
+			t.Errorf("%s token: %v", name, err)
+		}
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "b" || parsed.Method.Alg() != "RS256" {
+		t.Errorf("new token kid = %v, alg = %s, want b, RS256", parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	// a broken directory keeps the keys loaded before
+	if err := os.WriteFile(filepath.Join(dir, SigningKeyIDFile), []byte("missing"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Reload(); err == nil {
+		t.Fatal("Reload() with an unknown signing key id returned no error")
+	}
+	if _, err := a.ValidateToken(newToken); err != nil {
+		t.Errorf("token after failed reload: %v", err)
+	}
+}
+
+func TestKeySetAuthenticatorJWKS(t *testing.T) {
+	dir := t.TempDir()
+	edKey := newEd25519Key(t)
+	rsaKey := newRSAKey(t, 2048)
+	writePrivateKey(t, dir, "b-ed", edKey)
+	writePublicKey(t, dir, "a-rsa", rsaKey.Public())
+
+	a, err := NewKeySetAuthenticator(dir, "", testIssuer, testAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	want := []JWK{
+		{Kty: "RSA", Kid: "a-rsa", Use: "sig", Alg: "RS256", N: b64(rsaKey.N.Bytes()), E: "AQAB"},
+		{Kty: "OKP", Kid: "b-ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: b64(edKey.Public().(ed25519.PublicKey))},
+	}
+
+	got := a.JWKS().Keys
+	if len(got) != len(want) {
+		t.Fatalf("JWKS() has %d keys, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("JWKS().Keys[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
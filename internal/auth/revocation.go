@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// NewTokenID returns a random ID for the jti claim of an access token.
+func NewTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// RevocationCache remembers revoked token IDs until the tokens would have expired, and
+// token IDs found not to be revoked for a short TTL, so only a cache miss reaches the
+// database. A token revoked on another instance is therefore accepted here for at most
+// that TTL.
+type RevocationCache struct {
+	mu         sync.Mutex
+	entries    map[string]revocationEntry
+	validTTL   time.Duration
+	lastPruned time.Time
+}
+
+type revocationEntry struct {
+	revoked bool
+	until   time.Time
+}
+
+// revocationPruneInterval is how often Add drops entries that have expired.
+const revocationPruneInterval = time.Minute
+
+// NewRevocationCache returns a cache that remembers tokens found not to be revoked for
+// validTTL. A zero validTTL only caches revoked tokens.
+func NewRevocationCache(validTTL time.Duration) *RevocationCache {
+	return &RevocationCache{entries: map[string]revocationEntry{}, validTTL: validTTL}
+}
+
+// Add caches jti as revoked until expiresAt.
+func (r *RevocationCache) Add(jti string, expiresAt time.Time) {
+	r.set(jti, revocationEntry{revoked: true, until: expiresAt})
+}
+
+// AddValid caches jti as not revoked for the cache's TTL, or until expiresAt if sooner.
+func (r *RevocationCache) AddValid(jti string, expiresAt time.Time) {
+	if r.validTTL <= 0 {
+		return
+	}
+
+	until := time.Now().Add(r.validTTL)
+	if expiresAt.Before(until) {
+		until = expiresAt
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// never overwrite a revocation recorded while the database was being checked
+	if entry, ok := r.entries[jti]; ok && entry.revoked {
+		return
+	}
+
+	r.setLocked(jti, revocationEntry{until: until})
+}
+
+// Lookup reports whether jti is revoked and whether the answer came from the cache.
+// The database has to be checked when cached is false.
+func (r *RevocationCache) Lookup(jti string) (revoked, cached bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[jti]
+	if !ok {
+		return false, false
+	}
+	if !entry.until.After(time.Now()) {
+		delete(r.entries, jti)
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+func (r *RevocationCache) set(jti string, entry revocationEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.setLocked(jti, entry)
+}
+
+func (r *RevocationCache) setLocked(jti string, entry revocationEntry) {
+	now := time.Now()
+	if !entry.until.After(now) {
+		return
+	}
+
+	if now.Sub(r.lastPruned) > revocationPruneInterval {
+		for id, e := range r.entries {
+			if !e.until.After(now) {
+				delete(r.entries, id)
+			}
+		}
+		r.lastPruned = now
+	}
+
+	r.entries[jti] = entry
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevocationCacheLookup(t *testing.T) {
+	hour := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name        string
+		validTTL    time.Duration
+		setup       func(r *RevocationCache)
+		wantRevoked bool
+		wantCached  bool
+	}{
+		{
+			name:     "unknown token",
+			validTTL: time.Minute,
+			setup:    func(r *RevocationCache) {},
+		},
+		{
+			name:        "revoked",
+			validTTL:    time.Minute,
+			setup:       func(r *RevocationCache) { r.Add("jti", hour) },
+			wantRevoked: true,
+			wantCached:  true,
+		},
+		{
+			name:     "revoked token already expired",
+			validTTL: time.Minute,
+			setup:    func(r *RevocationCache) { r.Add("jti", past) },
+		},
+		{
+			name:       "valid",
+			validTTL:   time.Minute,
+			setup:      func(r *RevocationCache) { r.AddValid("jti", hour) },
+			wantCached: true,
+		},
+		{
+			name:  "valid not cached without a ttl",
+			setup: func(r *RevocationCache) { r.AddValid("jti", hour) },
+		},
+		{
+			name:     "valid token already expired",
+			validTTL: time.Minute,
+			setup:    func(r *RevocationCache) { r.AddValid("jti", past) },
+		},
+		{
+			name:     "valid does not overwrite a revocation",
+			validTTL: time.Minute,
+			setup: func(r *RevocationCache) {
+				r.Add("jti", hour)
+				r.AddValid("jti", hour)
+			},
+			wantRevoked: true,
+			wantCached:  true,
+		},
+		{
+			name:     "revocation overwrites valid",
+			validTTL: time.Minute,
+			setup: func(r *RevocationCache) {
+				r.AddValid("jti", hour)
+				r.Add("jti", hour)
+			},
+			wantRevoked: true,
+			wantCached:  true,
+		},
+		{
+			name:     "other token",
+			validTTL: time.Minute,
+			setup:    func(r *RevocationCache) { r.Add("other", hour) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRevocationCache(tt.validTTL)
+			tt.setup(r)
+
+			revoked, cached := r.Lookup("jti")
+			if revoked != tt.wantRevoked || cached != tt.wantCached {
+				t.Errorf("Lookup() = %v, %v, want %v, %v", revoked, cached, tt.wantRevoked, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestRevocationCacheEntriesExpire(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(r *RevocationCache, jti string, expiresAt time.Time)
+	}{
+		{"revoked until the token expires", (*RevocationCache).Add},
+		{"valid until the token expires", (*RevocationCache).AddValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRevocationCache(time.Minute)
+			tt.add(r, "jti", time.Now().Add(20*time.Millisecond))
+
+			if _, cached := r.Lookup("jti"); !cached {
+				t.Fatal("Lookup() missed before the entry expired")
+			}
+
+			time.Sleep(40 * time.Millisecond)
+
+			if revoked, cached := r.Lookup("jti"); revoked || cached {
+				t.Errorf("Lookup() = %v, %v after the entry expired, want false, false", revoked, cached)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// Package blob stores opaque binary objects such as delivery photos under string keys.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Storage is implemented by every blob backend. Keys are slash separated paths such
+// as "deliveries/<package id>/photo-<id>.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
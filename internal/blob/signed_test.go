@@ -0,0 +1,54 @@
+package blob
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestURLSignerVerify(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := now.Add(5 * time.Minute)
+	unix := strconv.FormatInt(expires.Unix(), 10)
+
+	signer := NewURLSigner("secret")
+	signature := signer.Sign("doc-1", expires)
+
+	tests := []struct {
+		name      string
+		signer    *URLSigner
+		id        string
+		expires   string
+		signature string
+		now       time.Time
+		want      error
+	}{
+		{"valid", signer, "doc-1", unix, signature, now, nil},
+		{"valid at expiry", signer, "doc-1", unix, signature, expires, nil},
+		{"expired", signer, "doc-1", unix, signature, expires.Add(time.Second), ErrLinkExpired},
+		{"other id", signer, "doc-2", unix, signature, now, ErrInvalidSignature},
+		{"extended expiry", signer, "doc-1", strconv.FormatInt(expires.Add(time.Hour).Unix(), 10), signature, now, ErrInvalidSignature},
+		{"malformed expiry", signer, "doc-1", "soon", signature, now, ErrInvalidSignature},
+		{"malformed signature", signer, "doc-1", unix, "not base64!", now, ErrInvalidSignature},
+		{"empty signature", signer, "doc-1", unix, "", now, ErrInvalidSignature},
+		{"other secret", NewURLSigner("other"), "doc-1", unix, signature, now, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signer.Verify(tt.id, tt.expires, tt.signature, tt.now); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLSignerSignIgnoresSubSecondExpiry(t *testing.T) {
+	signer := NewURLSigner("secret")
+	expires := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if signer.Sign("doc-1", expires) != signer.Sign("doc-1", expires.Add(500*time.Millisecond)) {
+		t.Error("Sign() differs within the same second, but links only carry Unix seconds")
+	}
+}
@@ -0,0 +1,81 @@
+// Package eligibility checks dispatcher applications against the requirements of the
+// applicant's region.
+package eligibility
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+// Applicant holds the application fields covered by a rule.
+type Applicant struct {
+	VehiclePlateNumber string
+	DriverLicense      string
+	VehicleMake        string
+	VehicleYear        int
+}
+
+// FieldErrors maps an application field to why it does not meet the rule.
+type FieldErrors map[string]string
+
+// CompilePattern compiles a rule pattern. Patterns must match the whole value, so
+// "[A-Z]{3}[0-9]{3}" only accepts six character values.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+// Validate checks the applicant against the rule and returns an error for every field
+// that does not meet it. Empty patterns, a zero minimum year or maximum age and an
+// empty list of makes are not enforced.
+func Validate(rule *models.ApplicationRule, a Applicant, now time.Time) (FieldErrors, error) {
+	errs := FieldErrors{}
+
+	if err := matchPattern(errs, "vehicle_plate_number", rule.PlatePattern, strings.TrimSpace(a.VehiclePlateNumber)); err != nil {
+		return nil, err
+	}
+
+	if err := matchPattern(errs, "driver_license", rule.LicensePattern, strings.TrimSpace(a.DriverLicense)); err != nil {
+		return nil, err
+	}
+
+	minYear := rule.MinVehicleYear
+	if rule.MaxVehicleAgeYears > 0 {
+		minYear = max(minYear, now.Year()-rule.MaxVehicleAgeYears)
+	}
+
+	switch {
+	case a.VehicleYear > now.Year()+1:
+		errs["vehicle_year"] = "vehicle year is in the future"
+	case minYear > 0 && a.VehicleYear < minYear:
+		errs["vehicle_year"] = fmt.Sprintf("vehicle must be from %d or later", minYear)
+	}
+
+	if len(rule.AllowedMakes) > 0 && !slices.ContainsFunc(rule.AllowedMakes, func(m string) bool {
+		return strings.EqualFold(m, strings.TrimSpace(a.VehicleMake))
+	}) {
+		errs["vehicle_make"] = "vehicle make must be one of " + strings.Join(rule.AllowedMakes, ", ")
+	}
+
+	return errs, nil
+}
+
+func matchPattern(errs FieldErrors, field, pattern, value string) error {
+	if pattern == "" {
+		return nil
+	}
+
+	re, err := CompilePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid %s pattern: %w", field, err)
+	}
+
+	if !re.MatchString(value) {
+		errs[field] = "invalid format for this region"
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package eligibility
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	rule := &models.ApplicationRule{
+		Region:             "lagos",
+		PlatePattern:       "[A-Z]{3}[0-9]{3}[A-Z]{2}",
+		LicensePattern:     "[A-Z]{3}[0-9]{5}",
+		MinVehicleYear:     2010,
+		MaxVehicleAgeYears: 10,
+		AllowedMakes:       []string{"Toyota", "Honda"},
+	}
+
+	valid := Applicant{VehiclePlateNumber: "ABC123DE", DriverLicense: "LAG12345", VehicleMake: "Toyota", VehicleYear: 2020}
+
+	tests := []struct {
+		name string
+		rule *models.ApplicationRule
+		edit func(a *Applicant)
+		want FieldErrors
+	}{
+		{
+			name: "valid",
+			rule: rule,
+			want: FieldErrors{},
+		},
+		{
+			name: "surrounding spaces and make case ignored",
+			rule: rule,
+			edit: func(a *Applicant) {
+				a.VehiclePlateNumber = " ABC123DE "
+				a.VehicleMake = " honda"
+			},
+			want: FieldErrors{},
+		},
+		{
+			name: "pattern must match the whole plate",
+			rule: rule,
+			edit: func(a *Applicant) { a.VehiclePlateNumber = "XABC123DE" },
+			want: FieldErrors{"vehicle_plate_number": "invalid format for this region"},
+		},
+		{
+			name: "bad license",
+			rule: rule,
+			edit: func(a *Applicant) { a.DriverLicense = "12345" },
+			want: FieldErrors{"driver_license": "invalid format for this region"},
+		},
+		{
+			name: "older than max age",
+			rule: rule,
+			edit: func(a *Applicant) { a.VehicleYear = 2014 },
+			want: FieldErrors{"vehicle_year": "vehicle must be from 2015 or later"},
+		},
+		{
+			name: "min year stricter than max age",
+			rule: &models.ApplicationRule{MinVehicleYear: 2018, MaxVehicleAgeYears: 10},
+			edit: func(a *Applicant) { a.VehicleYear = 2017 },
+			want: FieldErrors{"vehicle_year": "vehicle must be from 2018 or later"},
+		},
+		{
+			name: "next model year allowed",
+			rule: rule,
+			edit: func(a *Applicant) { a.VehicleYear = 2026 },
+			want: FieldErrors{},
+		},
+		{
+			name: "future year",
+			rule: &models.ApplicationRule{},
+			edit: func(a *Applicant) { a.VehicleYear = 2027 },
+			want: FieldErrors{"vehicle_year": "vehicle year is in the future"},
+		},
+		{
+			name: "make not allowed",
+			rule: rule,
+			edit: func(a *Applicant) { a.VehicleMake = "Ford" },
+			want: FieldErrors{"vehicle_make": "vehicle make must be one of Toyota, Honda"},
+		},
+		{
+			name: "every field",
+			rule: rule,
+			edit: func(a *Applicant) { *a = Applicant{VehicleYear: 1999} },
+			want: FieldErrors{
+				"vehicle_plate_number": "invalid format for this region",
+				"driver_license":       "invalid format for this region",
+				"vehicle_year":         "vehicle must be from 2015 or later",
+				"vehicle_make":         "vehicle make must be one of Toyota, Honda",
+			},
+		},
+		{
+			name: "empty rule enforces nothing",
+			rule: &models.ApplicationRule{},
+			edit: func(a *Applicant) { *a = Applicant{VehicleYear: 1990} },
+			want: FieldErrors{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			if tt.edit != nil {
+				tt.edit(&a)
+			}
+
+			got, err := Validate(tt.rule, a, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidPattern(t *testing.T) {
+	rule := &models.ApplicationRule{PlatePattern: "[A-Z"}
+
+	if _, err := Validate(rule, Applicant{VehiclePlateNumber: "ABC"}, time.Now()); err == nil {
+		t.Fatal("Validate() with an invalid pattern returned no error")
+	}
+}
@@ -0,0 +1,159 @@
+// Package listing parses the pagination, filtering and sorting parameters shared by list
+// endpoints and encodes the keyset cursors they page with.
+//
+// A list request looks like
+//
+//	?limit=50&sort=-created_at&role=admin&q=mike&created_from=2025-01-01&cursor=...
+//
+// where sort names a field, optionally prefixed with "-" for descending order, q is a
+// free-text search and any other supported field filters by equality.
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidQuery = errors.New("invalid list query")
+
+// Spec describes what a list endpoint supports. The first sort field is the default,
+// in descending order.
+type Spec struct {
+	Sorts        []string
+	Filters      []string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Query is a parsed list request.
+type Query struct {
+	Limit         int
+	Sort          string
+	Desc          bool
+	Filters       map[string]string
+	Search        string
+	CreatedAfter  *time.Time // inclusive
+	CreatedBefore *time.Time // exclusive
+	After         *Cursor    // set when continuing from a previous page
+}
+
+// Cursor identifies the last row of a page: the value of its sort field, and its ID to
+// break ties. It is bound to the sort it was issued for.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"i"`
+}
+
+// Page is the pagination metadata returned with a list.
+type Page struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// reserved are the parameters that are never treated as filters.
+var reserved = []string{"limit", "cursor", "sort", "q", "created_from", "created_to"}
+
+// Parse reads a list request from query string values. Errors wrap ErrInvalidQuery and
+// are safe to show to the client.
+func Parse(values url.Values, spec Spec) (*Query, error) {
+	q := &Query{
+		Limit:   spec.DefaultLimit,
+		Sort:    spec.Sorts[0],
+		Desc:    true,
+		Filters: map[string]string{},
+		Search:  strings.TrimSpace(values.Get("q")),
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > spec.MaxLimit {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, spec.MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		field, desc := strings.CutPrefix(raw, "-")
+		if !slices.Contains(spec.Sorts, field) {
+			return nil, fmt.Errorf("%w: sort must be one of %s", ErrInvalidQuery, strings.Join(spec.Sorts, ", "))
+		}
+		q.Sort, q.Desc = field, desc
+	}
+
+	for key, vals := range values {
+		if slices.Contains(reserved, key) || len(vals) == 0 || vals[0] == "" {
+			continue
+		}
+		if !slices.Contains(spec.Filters, key) {
+			return nil, fmt.Errorf("%w: unknown parameter %q", ErrInvalidQuery, key)
+		}
+		q.Filters[key] = vals[0]
+	}
+
+	var err error
+	if q.CreatedAfter, err = parseTime(values.Get("created_from"), "created_from"); err != nil {
+		return nil, err
+	}
+	if q.CreatedBefore, err = parseTime(values.Get("created_to"), "created_to"); err != nil {
+		return nil, err
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err != nil || cursor.Sort != q.Sort || cursor.Desc != q.Desc {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+		}
+		q.After = cursor
+	}
+
+	return q, nil
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates.
+func parseTime(raw, name string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s must be a date or RFC 3339 timestamp", ErrInvalidQuery, name)
+}
+
+// NextCursor returns the cursor continuing after a row with the given sort value and ID.
+func (q *Query) NextCursor(value, id string) Cursor {
+	return Cursor{Sort: q.Sort, Desc: q.Desc, Value: value, ID: id}
+}
+
+func EncodeCursor(c Cursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func DecodeCursor(raw string) (*Cursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
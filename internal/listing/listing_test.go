@@ -0,0 +1,146 @@
+package listing
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+var testSpec = Spec{
+	Sorts:        []string{"created_at", "username"},
+	Filters:      []string{"role"},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Sort: "created_at", Desc: true, Value: "2025-01-02T03:04:05.123456Z", ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"},
+		{Sort: "username", Value: "mike, \"the\" admin/+=", ID: "1"},
+		{},
+	}
+
+	for _, want := range tests {
+		raw := EncodeCursor(want)
+
+		got, err := DecodeCursor(raw)
+		if err != nil {
+			t.Fatalf("DecodeCursor(%q): %v", raw, err)
+		}
+		if *got != want {
+			t.Errorf("DecodeCursor(EncodeCursor(%+v)) = %+v", want, *got)
+		}
+		if _, err := url.ParseQuery("cursor=" + raw); err != nil {
+			t.Errorf("cursor %q is not safe in a query string: %v", raw, err)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, raw := range []string{"not base64!", "bm90IGpzb24", "e30="} {
+		if _, err := DecodeCursor(raw); err == nil {
+			t.Errorf("DecodeCursor(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort     string
+		wantSort string
+		wantDesc bool
+		wantErr  bool
+	}{
+		{"", "created_at", true, false},
+		{"created_at", "created_at", false, false},
+		{"-created_at", "created_at", true, false},
+		{"username", "username", false, false},
+		{"-username", "username", true, false},
+		{"password", "", false, true},
+		{"--username", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			q, err := Parse(url.Values{"sort": {tt.sort}}, testSpec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("err = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Sort != tt.wantSort || q.Desc != tt.wantDesc {
+				t.Errorf("sort = %q desc = %v, want %q desc = %v", q.Sort, q.Desc, tt.wantSort, tt.wantDesc)
+			}
+		})
+	}
+}
+
+// TestParseCursorBoundToSort checks a cursor is only accepted with the sort it was
+// issued for, since its value is meaningless under any other order.
+func TestParseCursorBoundToSort(t *testing.T) {
+	issued, err := Parse(url.Values{"sort": {"-created_at"}}, testSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor := EncodeCursor(issued.NextCursor("2025-01-02T03:04:05Z", "42"))
+
+	tests := []struct {
+		name    string
+		sort    string
+		wantErr bool
+	}{
+		{"same sort", "-created_at", false},
+		{"default sort", "", false},
+		{"other direction", "created_at", true},
+		{"other field", "-username", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := url.Values{"cursor": {cursor}}
+			if tt.sort != "" {
+				values.Set("sort", tt.sort)
+			}
+
+			q, err := Parse(values, testSpec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("err = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.After == nil || q.After.Value != "2025-01-02T03:04:05Z" || q.After.ID != "42" {
+				t.Errorf("After = %+v", q.After)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidQueries(t *testing.T) {
+	tests := []struct {
+		name   string
+		values url.Values
+	}{
+		{"zero limit", url.Values{"limit": {"0"}}},
+		{"limit above max", url.Values{"limit": {"101"}}},
+		{"non numeric limit", url.Values{"limit": {"ten"}}},
+		{"unknown filter", url.Values{"email": {"a@b.c"}}},
+		{"bad date", url.Values{"created_from": {"yesterday"}}},
+		{"garbage cursor", url.Values{"cursor": {"garbage"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.values, testSpec); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("err = %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
@@ -3,37 +3,127 @@ package models
 import "time"
 
 type User struct {
-	ID        string    `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"` // "user", "dispatcher", "admin"
-	Password  string    `json:"_"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"` // "user", "dispatcher", "admin"
+	Password string `json:"_"`
+	// TokensValidAfter is set when all of the user's tokens were revoked; access tokens
+	// issued before it are rejected.
+	TokensValidAfter *time.Time `json:"-"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"` // nil until the user follows the verification link
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RefreshToken is a stored refresh token. Tokens issued from the same login share a
+// FamilyID; only the hash of the token is kept.
+type RefreshToken struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	FamilyID    string     `json:"family_id"`
+	TokenHash   string     `json:"-"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at"` // set once the token has been exchanged
+	RevokedAt   *time.Time `json:"revoked_at"`
+	ReplacedBy  string     `json:"replaced_by"`
+	MFAVerified bool       `json:"mfa_verified"` // the login completed two-factor authentication
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// APIKey lets an integration call the API as UserID, limited to Scopes. Only the hash
+// of the key is stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // visible start of the key
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"` // nil for keys that do not expire
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// LoginThrottle counts recent failed logins for an account or a client IP.
+type LoginThrottle struct {
+	Kind          string     `json:"kind"`    // account, ip
+	Subject       string     `json:"subject"` // lowercased email or IP address
+	Failures      int        `json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// UserMFA is a user's TOTP authenticator. The secret is stored encrypted; EnabledAt is
+// nil until enrollment is confirmed with a valid code.
+type UserMFA struct {
+	UserID           string     `json:"user_id"`
+	SecretCiphertext string     `json:"-"`
+	EnabledAt        *time.Time `json:"enabled_at"`
+	LastUsedStep     int64      `json:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// UserToken is a single-use token sent to a user by email. Only its hash is stored.
+type UserToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Purpose   string     `json:"purpose"` // password_reset, email_verification
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 type Package struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	DispatcherID string    `json:"dispatcher_id"`
-	Origin       string    `json:"origin"`
-	Destination  string    `json:"destination"`
-	Status       string    `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                  string     `json:"id"`
+	UserID              string     `json:"user_id"`
+	DispatcherID        string     `json:"dispatcher_id"` // empty until a dispatcher is assigned
+	TrackingCode        string     `json:"tracking_code"`
+	Origin              string     `json:"origin"`
+	Destination         string     `json:"destination"`
+	RecipientName       string     `json:"recipient_name"`
+	RecipientPhone      string     `json:"recipient_phone"`
+	RecipientEmail      string     `json:"recipient_email"` // empty when the sender relays the delivery PIN
+	Description         string     `json:"description"`
+	WeightKg            float64    `json:"weight_kg"`
+	LengthCm            float64    `json:"length_cm"`
+	WidthCm             float64    `json:"width_cm"`
+	HeightCm            float64    `json:"height_cm"`
+	VehicleType         string     `json:"vehicle_type"`
+	OriginLat           float64    `json:"origin_lat"`
+	OriginLng           float64    `json:"origin_lng"`
+	DestinationLat      float64    `json:"destination_lat"`
+	DestinationLng      float64    `json:"destination_lng"`
+	Price               int64      `json:"price"` // in the currency's minor unit
+	Currency            string     `json:"currency"`
+	Status              string     `json:"status"`
+	EstimatedDeliveryAt *time.Time `json:"estimated_delivery_at"`
+	DeliveryPinHash     string     `json:"-"` // only set when the package is booked
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type DispatcherApplication struct {
-	ID                 string    `json:"id"`
-	UserID             string    `json:"user_id"`
-	VehicleType        string    `json:"vehicle_type"`
-	VehiclePlateNumber string    `json:"vehicle_plate_number"`
-	VehicleYear        int       `json:"vehicle_year"`
-	VehicleModel       string    `json:"vehicle_model"`
-	DriverLicense      string    `json:"driver_license"`
-	Status             string    `json:"status"` // pending, approved, rejected
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                 string     `json:"id"`
+	UserID             string     `json:"user_id"`
+	VehicleType        string     `json:"vehicle_type"`
+	VehiclePlateNumber string     `json:"vehicle_plate_number"`
+	VehicleYear        int        `json:"vehicle_year"`
+	VehicleModel       string     `json:"vehicle_model"`
+	DriverLicense      string     `json:"driver_license"`
+	Region             string     `json:"region"`
+	VehicleMake        string     `json:"vehicle_make"`
+	Status             string     `json:"status"` // pending, approved, rejected, withdrawn
+	ReviewedBy         string     `json:"reviewed_by"`
+	ReviewedAt         *time.Time `json:"reviewed_at"`
+	RejectionReason    string     `json:"rejection_reason"` // set when rejected
+	ReviewNote         string     `json:"review_note"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 type Dispatcher struct {
@@ -51,3 +141,170 @@ type Dispatcher struct {
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
+
+type PackageStatusEvent struct {
+	ID         string    `json:"id"`
+	PackageID  string    `json:"package_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    string    `json:"actor_id"` // empty for system transitions
+	Location   string    `json:"location"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VehicleType describes a kind of vehicle dispatchers deliver with. Inactive types are
+// kept for existing records but not offered for new applications or bookings.
+type VehicleType struct {
+	Code              string    `json:"code"`
+	Name              string    `json:"name"`
+	MaxWeightKg       float64   `json:"max_weight_kg"` // total load carried at once
+	MaxVolumeL        float64   `json:"max_volume_l"`  // total load volume in litres
+	AvgSpeedKmh       float64   `json:"avg_speed_kmh"` // door-to-door speed used for delivery estimates
+	PricingMultiplier float64   `json:"pricing_multiplier"`
+	IsActive          bool      `json:"is_active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Monetary amounts are stored in the currency's minor unit (e.g. kobo, cents).
+type PricingRate struct {
+	VehicleType       string    `json:"vehicle_type"`
+	Currency          string    `json:"currency"`
+	BaseFare          int64     `json:"base_fare"`
+	PerKm             int64     `json:"per_km"`
+	PerKg             int64     `json:"per_kg"`
+	MinFare           int64     `json:"min_fare"`
+	VolumetricDivisor int       `json:"volumetric_divisor"` // cm³ per chargeable kg
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type WeightTier struct {
+	ID          string  `json:"id"`
+	VehicleType string  `json:"vehicle_type"`
+	MinWeightKg float64 `json:"min_weight_kg"`
+	MaxWeightKg float64 `json:"max_weight_kg"`
+	Surcharge   int64   `json:"surcharge"`
+}
+
+// LocationPing is a single GPS fix reported by a dispatcher's device. Optional
+// readings are nil when the device did not provide them.
+type LocationPing struct {
+	Lat        float64   `json:"lat"`
+	Lng        float64   `json:"lng"`
+	AccuracyM  *float64  `json:"accuracy_m"`
+	Heading    *float64  `json:"heading"`
+	SpeedMps   *float64  `json:"speed_mps"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type DispatcherPosition struct {
+	DispatcherID string    `json:"dispatcher_id"`
+	UserID       string    `json:"user_id"`
+	VehicleType  string    `json:"vehicle_type"`
+	Lat          float64   `json:"lat"`
+	Lng          float64   `json:"lng"`
+	AccuracyM    *float64  `json:"accuracy_m"`
+	Heading      *float64  `json:"heading"`
+	SpeedMps     *float64  `json:"speed_mps"`
+	RecordedAt   time.Time `json:"recorded_at"`
+	DistanceKm   float64   `json:"distance_km"`
+}
+
+type DispatcherOffer struct {
+	ID           string     `json:"id"`
+	PackageID    string     `json:"package_id"`
+	DispatcherID string     `json:"dispatcher_id"`
+	Status       string     `json:"status"` // pending, accepted, declined, expired, cancelled
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RespondedAt  *time.Time `json:"responded_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type OfferStats struct {
+	Total          int     `json:"total"`
+	Pending        int     `json:"pending"`
+	Accepted       int     `json:"accepted"`
+	Declined       int     `json:"declined"`
+	Expired        int     `json:"expired"`
+	AcceptanceRate float64 `json:"acceptance_rate"` // accepted / (accepted + declined + expired)
+}
+
+// DeliveryProof is the evidence captured when a package is handed over. Attachment
+// keys refer to objects in blob storage and are empty for PIN confirmations.
+type DeliveryProof struct {
+	ID                   string    `json:"id"`
+	PackageID            string    `json:"package_id"`
+	Method               string    `json:"method"` // pin, signature_photo
+	SignatureKey         string    `json:"signature_key"`
+	SignatureContentType string    `json:"signature_content_type"`
+	PhotoKey             string    `json:"photo_key"`
+	PhotoContentType     string    `json:"photo_content_type"`
+	DeliveredBy          string    `json:"delivered_by"`
+	Location             string    `json:"location"`
+	Note                 string    `json:"note"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// ApplicationRule holds the dispatcher application requirements of a region. Zero
+// values are not enforced.
+type ApplicationRule struct {
+	Region             string    `json:"region"`
+	PlatePattern       string    `json:"plate_pattern"`   // regular expression matching the whole plate number
+	LicensePattern     string    `json:"license_pattern"` // regular expression matching the whole driver license
+	MinVehicleYear     int       `json:"min_vehicle_year"`
+	MaxVehicleAgeYears int       `json:"max_vehicle_age_years"`
+	AllowedMakes       []string  `json:"allowed_makes"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// ApplicationDocument is a file uploaded to support a dispatcher application. The file
+// itself lives in blob storage under BlobKey.
+type ApplicationDocument struct {
+	ID            string    `json:"id"`
+	ApplicationID string    `json:"application_id"`
+	Kind          string    `json:"kind"` // driver_license, vehicle_registration, insurance_certificate, selfie
+	BlobKey       string    `json:"-"`
+	ContentType   string    `json:"content_type"`
+	SizeBytes     int64     `json:"size_bytes"`
+	SHA256        string    `json:"sha256"` // hex encoded
+	UploadedBy    string    `json:"uploaded_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// ApplicationHistoryEntry records one change to a dispatcher application. Changes is
+// keyed by field name and only set for edits.
+type ApplicationHistoryEntry struct {
+	ID            string                 `json:"id"`
+	ApplicationID string                 `json:"application_id"`
+	ActorID       string                 `json:"actor_id"`
+	Action        string                 `json:"action"` // submitted, edited, withdrawn, approved, rejected
+	Changes       map[string]FieldChange `json:"changes"`
+	Note          string                 `json:"note"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// IdentifierRecord is one use of a plate number or driver license, by an application
+// or a dispatcher.
+type IdentifierRecord struct {
+	Source    string    `json:"source"` // application, dispatcher
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Value     string    `json:"value"`  // as entered
+	Status    string    `json:"status"` // application status, or active/inactive for dispatchers
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DuplicateIdentifier groups the records of different users whose plate numbers or
+// driver licenses are equal once case, spacing and punctuation are ignored.
+type DuplicateIdentifier struct {
+	Field      string             `json:"field"` // vehicle_plate_number, driver_license
+	Normalized string             `json:"normalized"`
+	Records    []IdentifierRecord `json:"records"`
+}
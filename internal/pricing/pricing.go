@@ -0,0 +1,135 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+var (
+	ErrWeightNotServed        = errors.New("no weight tier covers this package weight")
+	ErrInvalidRequest         = errors.New("invalid quote request")
+	ErrExceedsVehicleCapacity = errors.New("package exceeds the vehicle's capacity")
+)
+
+type Request struct {
+	Origin      geo.Point `json:"origin"`
+	Destination geo.Point `json:"destination"`
+	WeightKg    float64   `json:"weight_kg"`
+	LengthCm    float64   `json:"length_cm"`
+	WidthCm     float64   `json:"width_cm"`
+	HeightCm    float64   `json:"height_cm"`
+	VehicleType string    `json:"vehicle_type"`
+}
+
+type LineItem struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+}
+
+// Quote is an itemized delivery price. Amounts are in the currency's minor unit.
+type Quote struct {
+	Request
+	UserID             string     `json:"user_id"`
+	DistanceKm         float64    `json:"distance_km"`
+	EstimatedMinutes   int        `json:"estimated_minutes"`
+	ChargeableWeightKg float64    `json:"chargeable_weight_kg"`
+	Currency           string     `json:"currency"`
+	Items              []LineItem `json:"items"`
+	Total              int64      `json:"total"`
+	IssuedAt           time.Time  `json:"issued_at"`
+	ExpiresAt          time.Time  `json:"expires_at"`
+}
+
+// Calculate prices a request against a vehicle type's rate and weight tiers.
+// The chargeable weight is the greater of the actual and volumetric weight. The
+// vehicle's pricing multiplier applies to everything but the minimum fare.
+func Calculate(req Request, vehicle *models.VehicleType, rate *models.PricingRate, tiers []models.WeightTier, now time.Time, ttl time.Duration) (*Quote, error) {
+	if !req.Origin.Valid() || !req.Destination.Valid() || req.WeightKg <= 0 || req.LengthCm < 0 || req.WidthCm < 0 || req.HeightCm < 0 {
+		return nil, ErrInvalidRequest
+	}
+
+	if req.WeightKg > vehicle.MaxWeightKg || req.LengthCm*req.WidthCm*req.HeightCm/1000 > vehicle.MaxVolumeL {
+		return nil, ErrExceedsVehicleCapacity
+	}
+
+	distance := roundTo(geo.DistanceKm(req.Origin, req.Destination), 2)
+
+	chargeable := req.WeightKg
+	if rate.VolumetricDivisor > 0 {
+		volumetric := req.LengthCm * req.WidthCm * req.HeightCm / float64(rate.VolumetricDivisor)
+		chargeable = math.Max(chargeable, volumetric)
+	}
+	chargeable = roundTo(chargeable, 2)
+
+	tier, ok := findTier(tiers, chargeable)
+	if !ok {
+		return nil, ErrWeightNotServed
+	}
+
+	items := []LineItem{
+		{Code: "base_fare", Description: "Base fare", Amount: rate.BaseFare},
+		{Code: "distance", Description: fmt.Sprintf("Distance (%.2f km)", distance), Amount: int64(math.Round(distance * float64(rate.PerKm)))},
+		{Code: "weight", Description: fmt.Sprintf("Weight (%.2f kg)", chargeable), Amount: int64(math.Round(chargeable * float64(rate.PerKg)))},
+	}
+
+	if tier.Surcharge > 0 {
+		items = append(items, LineItem{
+			Code:        "weight_tier",
+			Description: fmt.Sprintf("Weight tier %.2f-%.2f kg", tier.MinWeightKg, tier.MaxWeightKg),
+			Amount:      tier.Surcharge,
+		})
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Amount
+	}
+
+	if vehicle.PricingMultiplier > 0 && vehicle.PricingMultiplier != 1 {
+		adjustment := int64(math.Round(float64(total) * (vehicle.PricingMultiplier - 1)))
+		items = append(items, LineItem{
+			Code:        "vehicle_multiplier",
+			Description: fmt.Sprintf("%s rate (x%.2f)", vehicle.Name, vehicle.PricingMultiplier),
+			Amount:      adjustment,
+		})
+		total += adjustment
+	}
+
+	if total < rate.MinFare {
+		items = append(items, LineItem{Code: "min_fare_adjustment", Description: "Minimum fare adjustment", Amount: rate.MinFare - total})
+		total = rate.MinFare
+	}
+
+	return &Quote{
+		Request:            req,
+		DistanceKm:         distance,
+		EstimatedMinutes:   int(math.Ceil(distance / vehicle.AvgSpeedKmh * 60)),
+		ChargeableWeightKg: chargeable,
+		Currency:           rate.Currency,
+		Items:              items,
+		Total:              total,
+		IssuedAt:           now.UTC(),
+		ExpiresAt:          now.Add(ttl).UTC(),
+	}, nil
+}
+
+// findTier returns the tier whose (min, max] range contains weight.
+func findTier(tiers []models.WeightTier, weight float64) (models.WeightTier, bool) {
+	for _, tier := range tiers {
+		if weight > tier.MinWeightKg && weight <= tier.MaxWeightKg {
+			return tier, true
+		}
+	}
+	return models.WeightTier{}, false
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
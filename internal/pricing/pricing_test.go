@@ -0,0 +1,175 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+var (
+	testVehicle = &models.VehicleType{Code: "van", Name: "Van", MaxWeightKg: 100, MaxVolumeL: 1000, AvgSpeedKmh: 30, PricingMultiplier: 1}
+	testRate    = &models.PricingRate{VehicleType: "van", Currency: "NGN", BaseFare: 50000, PerKm: 10000, PerKg: 5000, MinFare: 100000, VolumetricDivisor: 5000}
+	testTiers   = []models.WeightTier{
+		{MinWeightKg: 0, MaxWeightKg: 5, Surcharge: 0},
+		{MinWeightKg: 5, MaxWeightKg: 20, Surcharge: 20000},
+		{MinWeightKg: 20, MaxWeightKg: 50, Surcharge: 50000},
+	}
+
+	lagos = geo.Point{Lat: 6.5, Lng: 3.4}
+	// one degree of latitude north of lagos, 111.19 km away
+	north = geo.Point{Lat: 7.5, Lng: 3.4}
+)
+
+func TestCalculateTotals(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("WAT", 3600))
+
+	tests := []struct {
+		name        string
+		req         Request
+		multiplier  float64
+		wantItems   map[string]int64
+		wantTotal   int64
+		wantMinutes int
+	}{
+		{
+			name:      "minimum fare",
+			req:       Request{Origin: lagos, Destination: lagos, WeightKg: 2, LengthCm: 10, WidthCm: 10, HeightCm: 10},
+			wantItems: map[string]int64{"base_fare": 50000, "distance": 0, "weight": 10000, "min_fare_adjustment": 40000},
+			wantTotal: 100000,
+		},
+		{
+			name:        "distance and weight tier",
+			req:         Request{Origin: lagos, Destination: north, WeightKg: 10},
+			wantItems:   map[string]int64{"base_fare": 50000, "distance": 1111900, "weight": 50000, "weight_tier": 20000},
+			wantTotal:   1231900,
+			wantMinutes: 223,
+		},
+		{
+			name:        "vehicle multiplier",
+			req:         Request{Origin: lagos, Destination: north, WeightKg: 10},
+			multiplier:  1.5,
+			wantItems:   map[string]int64{"base_fare": 50000, "distance": 1111900, "weight": 50000, "weight_tier": 20000, "vehicle_multiplier": 615950},
+			wantTotal:   1847850,
+			wantMinutes: 223,
+		},
+		{
+			name:      "volumetric weight",
+			req:       Request{Origin: lagos, Destination: lagos, WeightKg: 1, LengthCm: 50, WidthCm: 40, HeightCm: 30},
+			wantItems: map[string]int64{"base_fare": 50000, "distance": 0, "weight": 60000, "weight_tier": 20000},
+			wantTotal: 130000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vehicle := *testVehicle
+			if tt.multiplier != 0 {
+				vehicle.PricingMultiplier = tt.multiplier
+			}
+
+			quote, err := Calculate(tt.req, &vehicle, testRate, testTiers, now, 15*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			items := map[string]int64{}
+			var sum int64
+			for _, item := range quote.Items {
+				items[item.Code] = item.Amount
+				sum += item.Amount
+			}
+
+			if len(items) != len(tt.wantItems) {
+				t.Errorf("items = %v, want %v", items, tt.wantItems)
+			}
+			for code, want := range tt.wantItems {
+				if got, ok := items[code]; !ok || got != want {
+					t.Errorf("item %s = %d, want %d", code, got, want)
+				}
+			}
+
+			if quote.Total != tt.wantTotal {
+				t.Errorf("Total = %d, want %d", quote.Total, tt.wantTotal)
+			}
+			if sum != quote.Total {
+				t.Errorf("items sum to %d, Total is %d", sum, quote.Total)
+			}
+			if quote.EstimatedMinutes != tt.wantMinutes {
+				t.Errorf("EstimatedMinutes = %d, want %d", quote.EstimatedMinutes, tt.wantMinutes)
+			}
+			if !quote.IssuedAt.Equal(now) || quote.IssuedAt.Location() != time.UTC || !quote.ExpiresAt.Equal(now.Add(15*time.Minute)) {
+				t.Errorf("IssuedAt = %v, ExpiresAt = %v", quote.IssuedAt, quote.ExpiresAt)
+			}
+		})
+	}
+}
+
+// TestCalculateWeightTierBoundaries checks tiers cover (min, max]: a weight equal to a
+// tier's maximum belongs to that tier, not the next one.
+func TestCalculateWeightTierBoundaries(t *testing.T) {
+	tests := []struct {
+		weightKg      float64
+		wantSurcharge int64
+		wantErr       error
+	}{
+		{0.01, 0, nil},
+		{5, 0, nil},
+		{5.001, 0, nil}, // chargeable weight is rounded to 2 places first
+		{5.01, 20000, nil},
+		{20, 20000, nil},
+		{20.01, 50000, nil},
+		{50, 50000, nil},
+		{50.01, 0, ErrWeightNotServed},
+	}
+
+	for _, tt := range tests {
+		req := Request{Origin: lagos, Destination: lagos, WeightKg: tt.weightKg}
+
+		quote, err := Calculate(req, testVehicle, testRate, testTiers, time.Now(), time.Minute)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("weight %v: err = %v, want %v", tt.weightKg, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("weight %v: %v", tt.weightKg, err)
+			continue
+		}
+
+		var surcharge int64
+		for _, item := range quote.Items {
+			if item.Code == "weight_tier" {
+				surcharge = item.Amount
+			}
+		}
+		if surcharge != tt.wantSurcharge {
+			t.Errorf("weight %v: tier surcharge = %d, want %d", tt.weightKg, surcharge, tt.wantSurcharge)
+		}
+	}
+}
+
+func TestCalculateRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr error
+	}{
+		{"zero weight", Request{Origin: lagos, Destination: north}, ErrInvalidRequest},
+		{"negative dimension", Request{Origin: lagos, Destination: north, WeightKg: 1, LengthCm: -1}, ErrInvalidRequest},
+		{"invalid origin", Request{Origin: geo.Point{Lat: 91}, Destination: north, WeightKg: 1}, ErrInvalidRequest},
+		{"too heavy for vehicle", Request{Origin: lagos, Destination: north, WeightKg: 101}, ErrExceedsVehicleCapacity},
+		{"too big for vehicle", Request{Origin: lagos, Destination: north, WeightKg: 1, LengthCm: 200, WidthCm: 100, HeightCm: 100}, ErrExceedsVehicleCapacity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Calculate(tt.req, testVehicle, testRate, testTiers, time.Now(), time.Minute); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
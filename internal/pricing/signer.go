@@ -0,0 +1,72 @@
+package pricing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidQuote = errors.New("invalid quote")
+	ErrQuoteExpired = errors.New("quote has expired")
+)
+
+// Signer turns quotes into tamper-proof tokens so a booking can be charged exactly
+// what was quoted without storing every quote.
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign encodes the quote as base64url(json) "." base64url(hmac-sha256).
+func (s *Signer) Sign(q *Quote) (string, error) {
+	payload, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded)), nil
+}
+
+// Verify checks the token signature and expiry and returns the quote it carries.
+func (s *Signer) Verify(token string, now time.Time) (*Quote, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidQuote
+	}
+
+	gotMac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotMac, s.mac(encoded)) {
+		return nil, ErrInvalidQuote
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+
+	var q Quote
+	if err := json.Unmarshal(payload, &q); err != nil {
+		return nil, ErrInvalidQuote
+	}
+
+	if now.After(q.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+
+	return &q, nil
+}
+
+func (s *Signer) mac(data string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,58 @@
+// Package ratelimit limits how often a key, such as a client IP or an email address,
+// may perform an action. State is kept in memory per process.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows up to limit events per key in each fixed window.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu         sync.Mutex
+	windows    map[string]*window
+	lastPruned time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func New(limit int, per time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: per, windows: map[string]*window{}}
+}
+
+// Allow records an event for key and reports whether it is within the limit. When it
+// is not, retryAfter is how long until the key's window resets.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPruned) > l.window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.lastPruned = now
+	}
+
+	w, found := l.windows[key]
+	if !found || now.Sub(w.start) >= l.window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+
+	w.count++
+	return true, 0
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+const (
+	APIKeyScopePackagesRead  = "packages:read"
+	APIKeyScopePackagesWrite = "packages:write" // includes requesting quotes
+	APIKeyScopeTrackingRead  = "tracking:read"
+)
+
+var apiKeyScopes = []string{APIKeyScopePackagesRead, APIKeyScopePackagesWrite, APIKeyScopeTrackingRead}
+
+// IsValidAPIKeyScope reports whether scope can be granted to an API key.
+func IsValidAPIKeyScope(scope string) bool {
+	return slices.Contains(apiKeyScopes, scope)
+}
+
+type APIKeyStore struct {
+	db DB
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, last_used_at, expires_at, revoked_at, created_at`
+
+func scanAPIKey(row rowScanner, key *models.APIKey) error {
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes), &lastUsedAt, &expiresAt, &revokedAt, &key.CreatedAt); err != nil {
+		return err
+	}
+
+	key.LastUsedAt, key.ExpiresAt, key.RevokedAt = nil, nil, nil
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return nil
+}
+
+func (a *APIKeyStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + apiKeyColumns
+
+	if err = scanAPIKey(tx.QueryRowContext(ctx, query, key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt), key); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAPIKeyByHash returns the key with the given hash, including revoked and expired
+// keys; callers check those.
+func (a *APIKeyStore) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	key := &models.APIKey{}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	if err := scanAPIKey(a.db.QueryRowContext(ctx, query, hash), key); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (a *APIKeyStore) GetUserAPIKeys(ctx context.Context, userId string) ([]models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := a.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err = scanAPIKey(rows, &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the key with the given id. When userId is set, only a key of
+// that user is revoked.
+func (a *APIKeyStore) RevokeAPIKey(ctx context.Context, id, userId string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 AND ($2 = '' OR user_id::text = $2)`
+
+	res, err := tx.ExecContext(ctx, query, id, userId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return tx.Commit()
+}
+
+// TouchAPIKey records that the key was used at the given time.
+func (a *APIKeyStore) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	_, err := a.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+// ApplicationDocumentKinds are the documents an applicant can upload, one of each.
+var ApplicationDocumentKinds = []string{
+	"driver_license",
+	"vehicle_registration",
+	"insurance_certificate",
+	"selfie",
+}
+
+// IsValidDocumentKind reports whether kind is one of ApplicationDocumentKinds.
+func IsValidDocumentKind(kind string) bool {
+	return slices.Contains(ApplicationDocumentKinds, kind)
+}
+
+const applicationDocumentColumns = `id, application_id, kind, blob_key, content_type, size_bytes, sha256, uploaded_by, created_at, updated_at`
+
+func scanApplicationDocument(row rowScanner, doc *models.ApplicationDocument) error {
+	var uploadedBy sql.NullString
+
+	if err := row.Scan(&doc.ID, &doc.ApplicationID, &doc.Kind, &doc.BlobKey, &doc.ContentType, &doc.SizeBytes, &doc.SHA256, &uploadedBy, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+		return err
+	}
+
+	doc.UploadedBy = uploadedBy.String
+	return nil
+}
+
+// SaveDocument stores the document of its kind for the application, replacing any
+// earlier upload. It returns the blob key of the replaced file, or "" if there was none,
+// so the caller can remove it once the new document is saved.
+func (d *DispatcherApplyStore) SaveDocument(ctx context.Context, doc *models.ApplicationDocument) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback()
+
+	var replacedKey string
+	if err = tx.QueryRowContext(ctx, `SELECT blob_key FROM application_documents WHERE application_id = $1 AND kind = $2 FOR UPDATE`, doc.ApplicationID, doc.Kind).Scan(&replacedKey); err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	query := `INSERT INTO application_documents (application_id, kind, blob_key, content_type, size_bytes, sha256, uploaded_by) VALUES ($1, $2, $3, $4, $5, $6, $7)
+              ON CONFLICT (application_id, kind) DO UPDATE SET blob_key = EXCLUDED.blob_key, content_type = EXCLUDED.content_type, size_bytes = EXCLUDED.size_bytes, sha256 = EXCLUDED.sha256, uploaded_by = EXCLUDED.uploaded_by, updated_at = NOW()
+              RETURNING ` + applicationDocumentColumns
+
+	if err = scanApplicationDocument(tx.QueryRowContext(ctx, query, doc.ApplicationID, doc.Kind, doc.BlobKey, doc.ContentType, doc.SizeBytes, doc.SHA256, nullString(doc.UploadedBy)), doc); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+
+	return replacedKey, nil
+}
+
+func (d *DispatcherApplyStore) GetDocumentsByApplicationId(ctx context.Context, applicationId string) (*[]models.ApplicationDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT ` + applicationDocumentColumns + ` FROM application_documents WHERE application_id = $1 ORDER BY kind`
+
+	docs := []models.ApplicationDocument{}
+
+	rows, err := d.db.QueryContext(ctx, query, applicationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc models.ApplicationDocument
+		if err = scanApplicationDocument(rows, &doc); err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &docs, nil
+}
+
+func (d *DispatcherApplyStore) GetDocumentById(ctx context.Context, id string) (*models.ApplicationDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	doc := &models.ApplicationDocument{}
+
+	query := `SELECT ` + applicationDocumentColumns + ` FROM application_documents WHERE id = $1`
+
+	if err := scanApplicationDocument(d.db.QueryRowContext(ctx, query, id), doc); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, err
+	}
+
+	return doc, nil
+}
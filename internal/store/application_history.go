@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+// lockPendingApplication locks the application row for the rest of tx and returns it.
+// It returns ErrApplicationNotPending if the application can no longer be changed by
+// the applicant.
+func lockPendingApplication(ctx context.Context, tx Tx, id string) (*models.DispatcherApplication, error) {
+	current := &models.DispatcherApplication{}
+
+	if err := scanApplication(tx.QueryRowContext(ctx, `SELECT `+applicationColumns+` FROM dispatchers_apply WHERE id = $1 FOR UPDATE`, id), current); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDispatcherApplicationNotFound
+		}
+		return nil, err
+	}
+
+	if current.Status != ApplicationStatusPending {
+		return nil, ErrApplicationNotPending
+	}
+
+	return current, nil
+}
+
+// applicationChanges lists the applicant-editable fields that differ between two
+// versions of an application.
+func applicationChanges(from, to *models.DispatcherApplication) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+
+	add := func(field string, a, b any) {
+		if a != b {
+			changes[field] = models.FieldChange{From: a, To: b}
+		}
+	}
+
+	add("vehicle_type", from.VehicleType, to.VehicleType)
+	add("vehicle_plate_number", from.VehiclePlateNumber, to.VehiclePlateNumber)
+	add("vehicle_year", from.VehicleYear, to.VehicleYear)
+	add("vehicle_model", from.VehicleModel, to.VehicleModel)
+	add("driver_license", from.DriverLicense, to.DriverLicense)
+	add("region", from.Region, to.Region)
+	add("vehicle_make", from.VehicleMake, to.VehicleMake)
+
+	return changes
+}
+
+// UpdateApplication saves the applicant's edits to a pending application and records
+// the changed fields in its history. Nothing is written if no field changed.
+func (d *DispatcherApplyStore) UpdateApplication(ctx context.Context, application *models.DispatcherApplication, actorId string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	current, err := lockPendingApplication(ctx, tx, application.ID)
+	if err != nil {
+		return err
+	}
+
+	changes := applicationChanges(current, application)
+	if len(changes) == 0 {
+		*application = *current
+		return nil
+	}
+
+	query := `UPDATE dispatchers_apply SET vehicle_type = $1, vehicle_plate_number = $2, vehicle_year = $3, vehicle_model = $4, driver_license = $5, region = $6, vehicle_make = $7, updated_at = NOW()
+              WHERE id = $8 RETURNING ` + applicationColumns
+
+	if err = scanApplication(tx.QueryRowContext(ctx, query, application.VehicleType, application.VehiclePlateNumber, application.VehicleYear, application.VehicleModel, application.DriverLicense, application.Region, application.VehicleMake, application.ID), application); err != nil {
+		return asDuplicateError(err)
+	}
+
+	if err = insertApplicationHistory(ctx, tx, &models.ApplicationHistoryEntry{
+		ApplicationID: application.ID,
+		ActorID:       actorId,
+		Action:        "edited",
+		Changes:       changes,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WithdrawApplication withdraws a pending application on behalf of the applicant.
+func (d *DispatcherApplyStore) WithdrawApplication(ctx context.Context, id, actorId, note string) (*models.DispatcherApplication, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = lockPendingApplication(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	application := &models.DispatcherApplication{}
+
+	query := `UPDATE dispatchers_apply SET status = 'withdrawn', updated_at = NOW() WHERE id = $1 RETURNING ` + applicationColumns
+
+	if err = scanApplication(tx.QueryRowContext(ctx, query, id), application); err != nil {
+		return nil, err
+	}
+
+	if err = insertApplicationHistory(ctx, tx, &models.ApplicationHistoryEntry{
+		ApplicationID: id,
+		ActorID:       actorId,
+		Action:        "withdrawn",
+		Note:          note,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+func insertApplicationHistory(ctx context.Context, tx Tx, entry *models.ApplicationHistoryEntry) error {
+	if entry.Changes == nil {
+		entry.Changes = map[string]models.FieldChange{}
+	}
+
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO application_history (application_id, actor_id, action, changes, note) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+
+	return tx.QueryRowContext(ctx, query, entry.ApplicationID, nullString(entry.ActorID), entry.Action, changes, entry.Note).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (d *DispatcherApplyStore) GetApplicationHistory(ctx context.Context, applicationId string) (*[]models.ApplicationHistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT id, application_id, actor_id, action, changes, note, created_at FROM application_history WHERE application_id = $1 ORDER BY created_at, id`
+
+	entries := []models.ApplicationHistoryEntry{}
+
+	rows, err := d.db.QueryContext(ctx, query, applicationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.ApplicationHistoryEntry
+		var actorId sql.NullString
+		var changes []byte
+		if err = rows.Scan(&e.ID, &e.ApplicationID, &actorId, &e.Action, &changes, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+
+		e.ActorID = actorId.String
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &entries, nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+type ApplicationRuleStore struct {
+	db DB
+}
+
+const applicationRuleColumns = `region, plate_pattern, license_pattern, min_vehicle_year, max_vehicle_age_years, allowed_makes, updated_at`
+
+func scanApplicationRule(row rowScanner, rule *models.ApplicationRule) error {
+	return row.Scan(&rule.Region, &rule.PlatePattern, &rule.LicensePattern, &rule.MinVehicleYear, &rule.MaxVehicleAgeYears, pq.Array(&rule.AllowedMakes), &rule.UpdatedAt)
+}
+
+func (a *ApplicationRuleStore) GetRule(ctx context.Context, region string) (*models.ApplicationRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	rule := &models.ApplicationRule{}
+
+	query := `SELECT ` + applicationRuleColumns + ` FROM application_rules WHERE region = $1`
+
+	if err := scanApplicationRule(a.db.QueryRowContext(ctx, query, region), rule); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrApplicationRuleNotFound
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (a *ApplicationRuleStore) GetAllRules(ctx context.Context) (*[]models.ApplicationRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT ` + applicationRuleColumns + ` FROM application_rules ORDER BY region`
+
+	rules := []models.ApplicationRule{}
+
+	rows, err := a.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.ApplicationRule
+		if err = scanApplicationRule(rows, &r); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+func (a *ApplicationRuleStore) UpsertRule(ctx context.Context, rule *models.ApplicationRule) (*models.ApplicationRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `INSERT INTO application_rules (region, plate_pattern, license_pattern, min_vehicle_year, max_vehicle_age_years, allowed_makes) VALUES ($1, $2, $3, $4, $5, $6)
+              ON CONFLICT (region) DO UPDATE SET plate_pattern = EXCLUDED.plate_pattern, license_pattern = EXCLUDED.license_pattern, min_vehicle_year = EXCLUDED.min_vehicle_year, max_vehicle_age_years = EXCLUDED.max_vehicle_age_years, allowed_makes = EXCLUDED.allowed_makes, updated_at = NOW()
+              RETURNING ` + applicationRuleColumns
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if rule.AllowedMakes == nil {
+		rule.AllowedMakes = []string{}
+	}
+
+	if err = scanApplicationRule(tx.QueryRowContext(ctx, query, rule.Region, rule.PlatePattern, rule.LicensePattern, rule.MinVehicleYear, rule.MaxVehicleAgeYears, pq.Array(rule.AllowedMakes)), rule); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (a *ApplicationRuleStore) DeleteRule(ctx context.Context, region string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM application_rules WHERE region = $1`, region)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrApplicationRuleNotFound
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+const (
+	// maxActiveJobs caps how many undelivered packages a dispatcher holds or is offered at once.
+	maxActiveJobs = 5
+
+	// Ranking weights. Each factor is normalised to [0, 1] before weighting.
+	ratingWeight   = 0.3
+	workloadWeight = 0.3
+	distanceWeight = 0.4
+
+	// distanceHalfScoreKm is the pickup distance at which the distance factor halves.
+	distanceHalfScoreKm = 5.0
+)
+
+// activePackageStatuses are the statuses that count toward a dispatcher's workload.
+const activePackageStatuses = `('awaiting_pickup', 'picked_up', 'in_transit', 'out_for_delivery')`
+
+type AssignmentStore struct {
+	db DB
+}
+
+// load is the weight and volume of a package, or the total a dispatcher carries.
+type load struct {
+	weightKg float64
+	volumeL  float64
+}
+
+// fits reports whether adding pkg to the current load stays within the vehicle's capacity.
+func (l load) fits(pkg load, vehicle *models.VehicleType) bool {
+	return l.weightKg+pkg.weightKg <= vehicle.MaxWeightKg && l.volumeL+pkg.volumeL <= vehicle.MaxVolumeL
+}
+
+type assignmentCandidate struct {
+	dispatcherID string
+	rating       float64
+	activeJobs   int
+	carrying     load
+	position     *geo.Point
+	score        float64
+}
+
+// OfferPackage picks the best active dispatcher for a package that is still waiting for
+// one and offers it to them until ttl elapses. Dispatchers who declined the package or
+// let an offer for it expire are skipped until reofferAfter has passed, so once every
+// candidate has had a turn the package cascades through them again. The package row and the chosen dispatcher row are locked for the
+// whole transaction, and busy dispatchers are skipped rather than waited on, so concurrent
+// offers across instances never double-book either side. Every attempt is recorded on the
+// package, including ones that find no dispatcher, so the sweeper can retry the least
+// recently attempted packages first.
+func (a *AssignmentStore) OfferPackage(ctx context.Context, packageId string, ttl, reofferAfter time.Duration) (*models.DispatcherOffer, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var status, vehicleType string
+	var dispatcherId sql.NullString
+	var pkg load
+	var pickup geo.Point
+
+	query := `SELECT status, dispatcher_id, vehicle_type, weight_kg, length_cm * width_cm * height_cm / 1000, origin_lat, origin_lng FROM packages WHERE id = $1 FOR UPDATE`
+
+	if err = tx.QueryRowContext(ctx, query, packageId).Scan(&status, &dispatcherId, &vehicleType, &pkg.weightKg, &pkg.volumeL, &pickup.Lat, &pickup.Lng); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+
+	if dispatcherId.Valid {
+		return nil, ErrPackageAlreadyAssigned
+	}
+
+	if status != PackageStatusCreated {
+		return nil, &InvalidTransitionError{From: status, To: PackageStatusAwaitingPickup}
+	}
+
+	var pending bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM dispatcher_offers WHERE package_id = $1 AND status = 'pending')`, packageId).Scan(&pending); err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrOfferPending
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE packages SET last_offer_attempt_at = NOW() WHERE id = $1`, packageId); err != nil {
+		return nil, err
+	}
+
+	vehicle := &models.VehicleType{}
+	if err = scanVehicleType(tx.QueryRowContext(ctx, `SELECT `+vehicleTypeColumns+` FROM vehicle_types WHERE code = $1`, vehicleType), vehicle); err != nil {
+		return nil, err
+	}
+
+	candidates, err := findAssignmentCandidates(ctx, tx, packageId, vehicle, pkg, reofferAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	rankCandidates(candidates, pickup)
+
+	for _, candidate := range candidates {
+		locked, err := lockDispatcherWithCapacity(ctx, tx, candidate.dispatcherID, vehicle, pkg)
+		if err != nil {
+			return nil, err
+		}
+		if !locked {
+			continue
+		}
+
+		insertQuery := `INSERT INTO dispatcher_offers (package_id, dispatcher_id, expires_at) VALUES ($1, $2, NOW() + make_interval(secs => $3)) RETURNING ` + offerColumns
+
+		offer, err := scanOffer(tx.QueryRowContext(ctx, insertQuery, packageId, candidate.dispatcherID, ttl.Seconds()))
+		if err != nil {
+			return nil, err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+
+		return offer, nil
+	}
+
+	// keep the attempt so the package moves to the back of the sweeper's queue
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrNoDispatcherAvailable
+}
+
+// dispatcherWorkload sums the packages a dispatcher holds or has been offered. Pending
+// offers reserve capacity so a dispatcher is never offered more than they can carry.
+const dispatcherWorkload = `SELECT COUNT(*) AS jobs, COALESCE(SUM(p.weight_kg), 0) AS load_kg, COALESCE(SUM(p.length_cm * p.width_cm * p.height_cm), 0) / 1000 AS load_l FROM packages p
+              WHERE (p.dispatcher_id = d.id AND p.status IN ` + activePackageStatuses + `)
+                 OR p.id IN (SELECT o.package_id FROM dispatcher_offers o WHERE o.dispatcher_id = d.id AND o.status = 'pending')`
+
+// findAssignmentCandidates lists active dispatchers with the package's vehicle type that
+// still have room for it and have not answered or let expire an offer for it within
+// reofferAfter.
+func findAssignmentCandidates(ctx context.Context, tx Tx, packageId string, vehicle *models.VehicleType, pkg load, reofferAfter time.Duration) ([]*assignmentCandidate, error) {
+	query := `SELECT d.id, d.rating, pos.lat, pos.lng, w.jobs, w.load_kg, w.load_l
+              FROM dispatchers d
+              LEFT JOIN dispatcher_last_positions pos ON pos.dispatcher_id = d.id
+              CROSS JOIN LATERAL (` + dispatcherWorkload + `) w
+              WHERE d.isactive = TRUE AND d.vehicle_type = $1
+                AND NOT EXISTS (SELECT 1 FROM dispatcher_offers o WHERE o.package_id = $2 AND o.dispatcher_id = d.id
+                                AND COALESCE(o.responded_at, o.expires_at) > NOW() - make_interval(secs => $3))`
+
+	candidates := []*assignmentCandidate{}
+
+	rows, err := tx.QueryContext(ctx, query, vehicle.Code, packageId, reofferAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c assignmentCandidate
+		var lat, lng sql.NullFloat64
+		if err = rows.Scan(&c.dispatcherID, &c.rating, &lat, &lng, &c.activeJobs, &c.carrying.weightKg, &c.carrying.volumeL); err != nil {
+			return nil, err
+		}
+
+		if lat.Valid && lng.Valid {
+			c.position = &geo.Point{Lat: lat.Float64, Lng: lng.Float64}
+		}
+
+		if c.activeJobs < maxActiveJobs && c.carrying.fits(pkg, vehicle) {
+			candidates = append(candidates, &c)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// rankCandidates scores candidates by rating, spare workload and distance to pickup and
+// sorts them best first. Dispatchers without a known position get no distance credit.
+func rankCandidates(candidates []*assignmentCandidate, pickup geo.Point) {
+	for _, c := range candidates {
+		rating := math.Min(math.Max(c.rating, 0), 5) / 5
+		workload := 1 - float64(c.activeJobs)/maxActiveJobs
+
+		distance := 0.0
+		if c.position != nil {
+			distance = 1 / (1 + geo.DistanceKm(*c.position, pickup)/distanceHalfScoreKm)
+		}
+
+		c.score = ratingWeight*rating + workloadWeight*workload + distanceWeight*distance
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+}
+
+// lockDispatcherWithCapacity locks the dispatcher row, skipping it if another transaction
+// holds it, and re-checks capacity now that no one else can assign to it.
+func lockDispatcherWithCapacity(ctx context.Context, tx Tx, dispatcherId string, vehicle *models.VehicleType, pkg load) (bool, error) {
+	var id string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM dispatchers WHERE id = $1 AND isactive = TRUE FOR UPDATE SKIP LOCKED`, dispatcherId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var activeJobs int
+	var current load
+	query := `SELECT w.jobs, w.load_kg, w.load_l FROM dispatchers d CROSS JOIN LATERAL (` + dispatcherWorkload + `) w WHERE d.id = $1`
+
+	if err = tx.QueryRowContext(ctx, query, dispatcherId).Scan(&activeJobs, &current.weightKg, &current.volumeL); err != nil {
+		return false, err
+	}
+
+	return activeJobs < maxActiveJobs && current.fits(pkg, vehicle), nil
+}
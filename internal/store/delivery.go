@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+const (
+	DeliveryMethodPin            = "pin"
+	DeliveryMethodSignaturePhoto = "signature_photo"
+)
+
+const deliveryProofColumns = `id, package_id, method, signature_key, signature_content_type, photo_key, photo_content_type, delivered_by, location, note, created_at`
+
+// ReserveDeliveryPinAttempt counts a PIN attempt against the package before the PIN is
+// checked and returns the hashed PIN with the attempts used so far. Reserving the attempt
+// in the same statement that reads the hash means concurrent requests cannot all pass
+// the limit check. It returns ErrDeliveryPinLocked once maxAttempts have been used and
+// ErrDeliveryPinNotSet once the PIN has been used or if the package was booked without one.
+func (p *PackageStore) ReserveDeliveryPinAttempt(ctx context.Context, id string, maxAttempts int) (string, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	var pinHash string
+	var attempts int
+
+	query := `UPDATE packages SET delivery_pin_attempts = delivery_pin_attempts + 1
+              WHERE id = $1 AND delivery_pin_hash IS NOT NULL AND delivery_pin_attempts < $2
+              RETURNING delivery_pin_hash, delivery_pin_attempts`
+
+	err := p.db.QueryRowContext(ctx, query, id, maxAttempts).Scan(&pinHash, &attempts)
+	if err == nil {
+		return pinHash, attempts, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", 0, err
+	}
+
+	// nothing was reserved, find out why
+	var storedHash sql.NullString
+	if err := p.db.QueryRowContext(ctx, `SELECT delivery_pin_hash, delivery_pin_attempts FROM packages WHERE id = $1`, id).Scan(&storedHash, &attempts); err != nil {
+		if err == sql.ErrNoRows {
+			return "", 0, ErrPackageNotFound
+		}
+		return "", 0, err
+	}
+
+	if !storedHash.Valid {
+		return "", attempts, ErrDeliveryPinNotSet
+	}
+
+	return "", attempts, ErrDeliveryPinLocked
+}
+
+// ResetDeliveryPinAttempts clears the attempts counted against the package once the
+// correct PIN has been submitted.
+func (p *PackageStore) ResetDeliveryPinAttempts(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, `UPDATE packages SET delivery_pin_attempts = 0 WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPackageNotFound
+	}
+
+	return nil
+}
+
+// ConfirmDelivery moves a package to delivered and stores its proof of delivery in the
+// same transaction. A PIN is single use, so it is cleared once the delivery is confirmed.
+func (p *PackageStore) ConfirmDelivery(ctx context.Context, proof *models.DeliveryProof, event *models.PackageStatusEvent) (*models.Package, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	event.ToStatus = PackageStatusDelivered
+
+	pack, err := transitionPackage(ctx, tx, proof.PackageID, event)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO delivery_proofs (package_id, method, signature_key, signature_content_type, photo_key, photo_content_type, delivered_by, location, note)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`
+
+	if err = tx.QueryRowContext(ctx, query,
+		proof.PackageID,
+		proof.Method,
+		nullString(proof.SignatureKey),
+		nullString(proof.SignatureContentType),
+		nullString(proof.PhotoKey),
+		nullString(proof.PhotoContentType),
+		nullString(proof.DeliveredBy),
+		proof.Location,
+		proof.Note,
+	).Scan(&proof.ID, &proof.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE packages SET delivery_pin_hash = NULL WHERE id = $1`, proof.PackageID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+func (p *PackageStore) GetDeliveryProof(ctx context.Context, packageId string) (*models.DeliveryProof, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	proof := &models.DeliveryProof{}
+	var signatureKey, signatureContentType, photoKey, photoContentType, deliveredBy sql.NullString
+
+	query := `SELECT ` + deliveryProofColumns + ` FROM delivery_proofs WHERE package_id = $1`
+
+	if err := p.db.QueryRowContext(ctx, query, packageId).Scan(&proof.ID, &proof.PackageID, &proof.Method, &signatureKey, &signatureContentType, &photoKey, &photoContentType, &deliveredBy, &proof.Location, &proof.Note, &proof.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDeliveryProofNotFound
+		}
+		return nil, err
+	}
+
+	proof.SignatureKey = signatureKey.String
+	proof.SignatureContentType = signatureContentType.String
+	proof.PhotoKey = photoKey.String
+	proof.PhotoContentType = photoContentType.String
+	proof.DeliveredBy = deliveredBy.String
+
+	return proof, nil
+}
+
+// nullString stores empty strings as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
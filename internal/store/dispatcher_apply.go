@@ -3,19 +3,65 @@ package store
 import (
 	"context"
 	"database/sql"
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/puremike/pcourierds/internal/listing"
 	"github.com/puremike/pcourierds/internal/models"
 )
 
 type DispatcherApplyStore struct {
-	db *sql.DB
+	db DB
+}
+
+const (
+	ApplicationStatusPending   = "pending"
+	ApplicationStatusApproved  = "approved"
+	ApplicationStatusRejected  = "rejected"
+	ApplicationStatusWithdrawn = "withdrawn"
+)
+
+// ApplicationRejectionReasons are the reason codes an admin can reject an application with.
+var ApplicationRejectionReasons = []string{
+	"invalid_plate_number",
+	"invalid_driver_license",
+	"vehicle_too_old",
+	"vehicle_not_supported",
+	"incomplete_information",
+	"failed_background_check",
+	"other",
+}
+
+// IsValidRejectionReason reports whether code is one of ApplicationRejectionReasons.
+func IsValidRejectionReason(code string) bool {
+	return slices.Contains(ApplicationRejectionReasons, code)
+}
+
+const applicationColumns = `id, user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, region, vehicle_make, status, reviewed_by, reviewed_at, rejection_reason, review_note, created_at, updated_at`
+
+func scanApplication(row rowScanner, application *models.DispatcherApplication) error {
+	var reviewedBy, rejectionReason sql.NullString
+	var reviewedAt sql.NullTime
+
+	if err := row.Scan(&application.ID, &application.UserID, &application.VehicleType, &application.VehiclePlateNumber, &application.VehicleYear, &application.VehicleModel, &application.DriverLicense, &application.Region, &application.VehicleMake, &application.Status, &reviewedBy, &reviewedAt, &rejectionReason, &application.ReviewNote, &application.CreatedAt, &application.UpdatedAt); err != nil {
+		return err
+	}
+
+	application.ReviewedBy = reviewedBy.String
+	application.RejectionReason = rejectionReason.String
+	application.ReviewedAt = nil
+	if reviewedAt.Valid {
+		application.ReviewedAt = &reviewedAt.Time
+	}
+	return nil
 }
 
 func (d *DispatcherApplyStore) DispatcherApplication(ctx context.Context, application *models.DispatcherApplication) (*models.DispatcherApplication, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
-	query := `INSERT INTO dispatchers_apply (user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, status) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, status, created_at`
+	query := `INSERT INTO dispatchers_apply (user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, region, vehicle_make, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING ` + applicationColumns
 
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -24,7 +70,18 @@ func (d *DispatcherApplyStore) DispatcherApplication(ctx context.Context, applic
 
 	defer tx.Rollback()
 
-	if err = tx.QueryRowContext(ctx, query, application.UserID, application.VehicleType, application.VehiclePlateNumber, application.VehicleYear, application.VehicleModel, application.DriverLicense, application.Status).Scan(&application.ID, &application.UserID, &application.VehicleType, &application.VehiclePlateNumber, &application.VehicleYear, &application.VehicleModel, &application.DriverLicense, &application.Status, &application.CreatedAt); err != nil {
+	if err = scanApplication(tx.QueryRowContext(ctx, query, application.UserID, application.VehicleType, application.VehiclePlateNumber, application.VehicleYear, application.VehicleModel, application.DriverLicense, application.Region, application.VehicleMake, application.Status), application); err != nil {
+		if isUniqueViolation(err, "idx_dispatchers_apply_active_user") {
+			return nil, ErrApplicationExists
+		}
+		return nil, asDuplicateError(err)
+	}
+
+	if err = insertApplicationHistory(ctx, tx, &models.ApplicationHistoryEntry{
+		ApplicationID: application.ID,
+		ActorID:       application.UserID,
+		Action:        "submitted",
+	}); err != nil {
 		return nil, err
 	}
 
@@ -35,34 +92,65 @@ func (d *DispatcherApplyStore) DispatcherApplication(ctx context.Context, applic
 	return application, nil
 }
 
-func (d *DispatcherApplyStore) GetAllApplications(ctx context.Context) (*[]models.DispatcherApplication, error) {
+// ApplicationListSpec is what GetAllApplications can sort and filter by.
+var ApplicationListSpec = listing.Spec{
+	Sorts:        []string{"created_at", "updated_at", "vehicle_year"},
+	Filters:      []string{"status", "vehicle_type", "region"},
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+var applicationListColumns = listColumns{
+	sorts:   map[string]string{"created_at": "a.created_at", "updated_at": "a.updated_at", "vehicle_year": "a.vehicle_year"},
+	filters: map[string]string{"status": "a.status", "vehicle_type": "a.vehicle_type", "region": "a.region"},
+	search: []string{
+		"a.vehicle_plate_number ILIKE %[1]s",
+		"a.driver_license ILIKE %[1]s",
+		"EXISTS (SELECT 1 FROM users u WHERE u.id = a.user_id AND (u.username ILIKE %[1]s OR u.email ILIKE %[1]s))",
+	},
+	createdAt: "a.created_at",
+	id:        "a.id",
+}
+
+func (d *DispatcherApplyStore) GetAllApplications(ctx context.Context, q *listing.Query) (*[]models.DispatcherApplication, *listing.Page, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
-	query := `SELECT id, user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, status, created_at FROM dispatchers_apply`
+	query, args := buildListQuery(`SELECT `+applicationColumns+` FROM dispatchers_apply a`, q, applicationListColumns)
 
 	var dispatchersApp []models.DispatcherApplication
 
-	rows, err := d.db.QueryContext(ctx, query)
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var d models.DispatcherApplication
-		if err = rows.Scan(&d.ID, &d.UserID, &d.VehicleType, &d.VehiclePlateNumber, &d.VehicleYear, &d.VehicleModel, &d.DriverLicense, &d.Status, &d.CreatedAt); err != nil {
-			return nil, err
+		if err = scanApplication(rows, &d); err != nil {
+			return nil, nil, err
 		}
 
 		dispatchersApp = append(dispatchersApp, d)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &dispatchersApp, nil
+	dispatchersApp, page := finishPage(q, dispatchersApp, func(a *models.DispatcherApplication) (string, string) {
+		switch q.Sort {
+		case "updated_at":
+			return a.UpdatedAt.Format(time.RFC3339Nano), a.ID
+		case "vehicle_year":
+			return strconv.Itoa(a.VehicleYear), a.ID
+		default:
+			return a.CreatedAt.Format(time.RFC3339Nano), a.ID
+		}
+	})
+
+	return &dispatchersApp, page, nil
 }
 
 func (d *DispatcherApplyStore) GetApplicationById(ctx context.Context, id string) (*models.DispatcherApplication, error) {
@@ -71,9 +159,9 @@ func (d *DispatcherApplyStore) GetApplicationById(ctx context.Context, id string
 
 	dispatcherApp := &models.DispatcherApplication{}
 
-	query := `SELECT id, user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, status, created_at FROM dispatchers_apply WHERE id = $1`
+	query := `SELECT ` + applicationColumns + ` FROM dispatchers_apply WHERE id = $1`
 
-	if err := d.db.QueryRowContext(ctx, query, id).Scan(&dispatcherApp.ID, &dispatcherApp.UserID, &dispatcherApp.VehicleType, &dispatcherApp.VehiclePlateNumber, &dispatcherApp.VehicleYear, &dispatcherApp.VehicleModel, &dispatcherApp.DriverLicense, &dispatcherApp.Status, &dispatcherApp.CreatedAt); err != nil {
+	if err := scanApplication(d.db.QueryRowContext(ctx, query, id), dispatcherApp); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrDispatcherApplicationNotFound
 		}
@@ -83,15 +171,16 @@ func (d *DispatcherApplyStore) GetApplicationById(ctx context.Context, id string
 	return dispatcherApp, nil
 }
 
+// GetApplicationByUserId returns the user's most recent application.
 func (d *DispatcherApplyStore) GetApplicationByUserId(ctx context.Context, userId string) (*models.DispatcherApplication, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
 	dispatcherApp := &models.DispatcherApplication{}
 
-	query := `SELECT id, user_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, status, created_at FROM dispatchers_apply WHERE user_id = $1`
+	query := `SELECT ` + applicationColumns + ` FROM dispatchers_apply WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
 
-	if err := d.db.QueryRowContext(ctx, query, userId).Scan(&dispatcherApp.ID, &dispatcherApp.UserID, &dispatcherApp.VehicleType, &dispatcherApp.VehiclePlateNumber, &dispatcherApp.VehicleYear, &dispatcherApp.VehicleModel, &dispatcherApp.DriverLicense, &dispatcherApp.Status, &dispatcherApp.CreatedAt); err != nil {
+	if err := scanApplication(d.db.QueryRowContext(ctx, query, userId), dispatcherApp); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrDispatcherApplicationNotFound
 		}
@@ -101,11 +190,15 @@ func (d *DispatcherApplyStore) GetApplicationByUserId(ctx context.Context, userI
 	return dispatcherApp, nil
 }
 
-func (d *DispatcherApplyStore) DeleteApplicationByUserId(ctx context.Context, userId string) error {
+// ReviewApplication records an admin's decision on a pending application. The status,
+// reviewer, rejection reason and note are taken from dispatch. It returns
+// ErrApplicationAlreadyReviewed if the application is no longer pending.
+func (d *DispatcherApplyStore) ReviewApplication(ctx context.Context, dispatch *models.DispatcherApplication) error {
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
-	query := `DELETE FROM dispatchers_apply WHERE user_id = $1`
+	query := `UPDATE dispatchers_apply SET status = $1, reviewed_by = $2, reviewed_at = NOW(), rejection_reason = $3, review_note = $4, updated_at = NOW()
+              WHERE id = $5 AND status = 'pending' RETURNING ` + applicationColumns
 
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -114,31 +207,24 @@ func (d *DispatcherApplyStore) DeleteApplicationByUserId(ctx context.Context, us
 
 	defer tx.Rollback()
 
-	if _, err := tx.ExecContext(ctx, query, userId); err != nil {
-		return err
+	if err = scanApplication(tx.QueryRowContext(ctx, query, dispatch.Status, nullString(dispatch.ReviewedBy), nullString(dispatch.RejectionReason), dispatch.ReviewNote, dispatch.ID), dispatch); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrApplicationAlreadyReviewed
+		}
+		return asDuplicateError(err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return err
+	entry := &models.ApplicationHistoryEntry{
+		ApplicationID: dispatch.ID,
+		ActorID:       dispatch.ReviewedBy,
+		Action:        dispatch.Status,
+		Note:          dispatch.ReviewNote,
 	}
-
-	return nil
-}
-
-func (d *DispatcherApplyStore) UpdateDispatchApplicationStatus(ctx context.Context, dispatch *models.DispatcherApplication, id string) error {
-	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
-	defer cancel()
-
-	query := `UPDATE dispatchers_apply SET status = $1 WHERE id = $2`
-
-	tx, err := d.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
+	if dispatch.RejectionReason != "" {
+		entry.Changes = map[string]models.FieldChange{"rejection_reason": {To: dispatch.RejectionReason}}
 	}
 
-	defer tx.Rollback()
-
-	if _, err = tx.ExecContext(ctx, query, dispatch.Status, id); err != nil {
+	if err = insertApplicationHistory(ctx, tx, entry); err != nil {
 		return err
 	}
 
@@ -8,7 +8,7 @@ import (
 )
 
 type DispatcherStore struct {
-	db *sql.DB
+	db DB
 }
 
 func (dp *DispatcherStore) CreateDispatcher(ctx context.Context, dispatcher *models.Dispatcher) error {
@@ -37,7 +37,7 @@ func (dp *DispatcherStore) CreateDispatcher(ctx context.Context, dispatcher *mod
 		dispatcher.IsActive,
 		dispatcher.Rating,
 	); err != nil {
-		return err
+		return asDuplicateError(err)
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -46,3 +46,21 @@ func (dp *DispatcherStore) CreateDispatcher(ctx context.Context, dispatcher *mod
 
 	return nil
 }
+
+func (dp *DispatcherStore) GetDispatcherByUserId(ctx context.Context, userId string) (*models.Dispatcher, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	dispatcher := &models.Dispatcher{}
+
+	query := `SELECT id, user_id, application_id, vehicle_type, vehicle_plate_number, vehicle_year, vehicle_model, driver_license, approved_at, isactive, rating, created_at FROM dispatchers WHERE user_id = $1`
+
+	if err := dp.db.QueryRowContext(ctx, query, userId).Scan(&dispatcher.ID, &dispatcher.UserID, &dispatcher.ApplicationID, &dispatcher.VehicleType, &dispatcher.VehiclePlateNumber, &dispatcher.VehicleYear, &dispatcher.VehicleModel, &dispatcher.DriverLicense, &dispatcher.ApprovedAt, &dispatcher.IsActive, &dispatcher.Rating, &dispatcher.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDispatcherNotFound
+		}
+		return nil, err
+	}
+
+	return dispatcher, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+// GetDuplicateIdentifiers reports plate numbers and driver licenses used by more than
+// one user across all applications, including rejected and withdrawn ones, and
+// dispatchers. An applicant's own approved application and dispatcher record are not a
+// duplicate of each other.
+func (d *DispatcherApplyStore) GetDuplicateIdentifiers(ctx context.Context) (*[]models.DuplicateIdentifier, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `WITH identifiers AS (
+                  SELECT 'application' AS source, id, user_id, 'vehicle_plate_number' AS field, vehicle_plate_number AS value, plate_normalized AS normalized, status, created_at FROM dispatchers_apply
+                  UNION ALL
+                  SELECT 'application', id, user_id, 'driver_license', driver_license, license_normalized, status, created_at FROM dispatchers_apply
+                  UNION ALL
+                  SELECT 'dispatcher', id, user_id, 'vehicle_plate_number', vehicle_plate_number, plate_normalized, CASE WHEN isactive THEN 'active' ELSE 'inactive' END, created_at FROM dispatchers
+                  UNION ALL
+                  SELECT 'dispatcher', id, user_id, 'driver_license', driver_license, license_normalized, CASE WHEN isactive THEN 'active' ELSE 'inactive' END, created_at FROM dispatchers
+              )
+              SELECT field, normalized, source, id, user_id, value, status, created_at FROM identifiers
+              WHERE normalized <> '' AND (field, normalized) IN (
+                  SELECT field, normalized FROM identifiers WHERE normalized <> '' GROUP BY field, normalized HAVING COUNT(DISTINCT user_id) > 1
+              )
+              ORDER BY field, normalized, created_at`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	duplicates := []models.DuplicateIdentifier{}
+	for rows.Next() {
+		var field, normalized string
+		var record models.IdentifierRecord
+		if err = rows.Scan(&field, &normalized, &record.Source, &record.ID, &record.UserID, &record.Value, &record.Status, &record.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if n := len(duplicates); n == 0 || duplicates[n-1].Field != field || duplicates[n-1].Normalized != normalized {
+			duplicates = append(duplicates, models.DuplicateIdentifier{Field: field, Normalized: normalized})
+		}
+		last := &duplicates[len(duplicates)-1]
+		last.Records = append(last.Records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &duplicates, nil
+}
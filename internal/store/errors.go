@@ -0,0 +1,57 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// isUniqueViolation reports whether err is a Postgres unique violation on the given constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// isForeignKeyViolation reports whether err is a Postgres foreign key violation.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// DuplicateError is returned when a vehicle plate number or driver license is already
+// held by another applicant or dispatcher.
+type DuplicateError struct {
+	Field string // vehicle_plate_number or driver_license
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s is already registered to another dispatcher", e.Field)
+}
+
+// identifierConstraints maps the indexes and trigger conflicts guarding dispatcher
+// identifiers to the field they protect.
+var identifierConstraints = map[string]string{
+	"idx_dispatchers_apply_plate_normalized":   "vehicle_plate_number",
+	"idx_dispatchers_apply_license_normalized": "driver_license",
+	"idx_dispatchers_plate_normalized":         "vehicle_plate_number",
+	"idx_dispatchers_license_normalized":       "driver_license",
+	"dispatcher_plate_taken":                   "vehicle_plate_number",
+	"dispatcher_license_taken":                 "driver_license",
+}
+
+// asDuplicateError converts a unique violation on a dispatcher identifier into a
+// *DuplicateError and returns any other error unchanged.
+func asDuplicateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return err
+	}
+	if field, ok := identifierConstraints[pqErr.Constraint]; ok {
+		return &DuplicateError{Field: field}
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/puremike/pcourierds/internal/listing"
+)
+
+// listColumns maps the fields of a listing.Query to the columns of a table.
+type listColumns struct {
+	sorts   map[string]string // sort field -> column
+	filters map[string]string // filter field -> column
+	// search holds predicates matched by the q parameter; %[1]s is replaced by the
+	// placeholder of the ILIKE pattern.
+	search    []string
+	createdAt string
+	id        string
+}
+
+// buildListQuery appends the filters, keyset condition, order and limit of q to base,
+// which must be a SELECT without a WHERE clause. One row more than the page size is
+// requested so that finishPage can tell whether another page follows.
+func buildListQuery(base string, q *listing.Query, cols listColumns) (string, []any) {
+	var conds []string
+	var args []any
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(q.Filters)) {
+		conds = append(conds, fmt.Sprintf("%s = %s", cols.filters[field], arg(q.Filters[field])))
+	}
+
+	if q.Search != "" && len(cols.search) > 0 {
+		pattern := arg("%" + escapeLike(q.Search) + "%")
+		var preds []string
+		for _, s := range cols.search {
+			preds = append(preds, fmt.Sprintf(s, pattern))
+		}
+		conds = append(conds, "("+strings.Join(preds, " OR ")+")")
+	}
+
+	if q.CreatedAfter != nil {
+		conds = append(conds, fmt.Sprintf("%s >= %s", cols.createdAt, arg(*q.CreatedAfter)))
+	}
+	if q.CreatedBefore != nil {
+		conds = append(conds, fmt.Sprintf("%s < %s", cols.createdAt, arg(*q.CreatedBefore)))
+	}
+
+	sortCol := cols.sorts[q.Sort]
+	op, dir := ">", "ASC"
+	if q.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if q.After != nil {
+		conds = append(conds, fmt.Sprintf("(%s, %s) %s (%s, %s)", sortCol, cols.id, op, arg(q.After.Value), arg(q.After.ID)))
+	}
+
+	query := base
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %s", sortCol, dir, cols.id, dir, arg(q.Limit+1))
+
+	return query, args
+}
+
+// finishPage trims the extra row fetched by buildListQuery and builds the page
+// metadata. cursor returns the sort value and ID of an item.
+func finishPage[T any](q *listing.Query, items []T, cursor func(*T) (string, string)) ([]T, *listing.Page) {
+	page := &listing.Page{Limit: q.Limit}
+
+	if len(items) > q.Limit {
+		items = items[:q.Limit]
+		page.HasMore = true
+		page.NextCursor = listing.EncodeCursor(q.NextCursor(cursor(&items[len(items)-1])))
+	}
+
+	return items, page
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
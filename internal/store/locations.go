@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+type LocationStore struct {
+	db DB
+}
+
+// kmPerDegreeLat is the approximate length of one degree of latitude.
+const kmPerDegreeLat = 111.045
+
+// RecordPings stores a batch of pings and moves the dispatcher's last known position
+// to the newest of them, unless a newer ping was already recorded.
+func (l *LocationStore) RecordPings(ctx context.Context, dispatcherId string, pings []models.LocationPing) error {
+	if len(pings) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	values := make([]string, 0, len(pings))
+	args := make([]any, 0, len(pings)*7)
+	latest := pings[0]
+
+	for i, ping := range pings {
+		n := i * 7
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+		args = append(args, dispatcherId, ping.Lat, ping.Lng, ping.AccuracyM, ping.Heading, ping.SpeedMps, ping.RecordedAt)
+
+		if ping.RecordedAt.After(latest.RecordedAt) {
+			latest = ping
+		}
+	}
+
+	insertQuery := `INSERT INTO dispatcher_location_pings (dispatcher_id, lat, lng, accuracy_m, heading, speed_mps, recorded_at) VALUES ` + strings.Join(values, ", ")
+
+	upsertQuery := `INSERT INTO dispatcher_last_positions (dispatcher_id, lat, lng, accuracy_m, heading, speed_mps, recorded_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+                    ON CONFLICT (dispatcher_id) DO UPDATE SET lat = EXCLUDED.lat, lng = EXCLUDED.lng, accuracy_m = EXCLUDED.accuracy_m, heading = EXCLUDED.heading, speed_mps = EXCLUDED.speed_mps, recorded_at = EXCLUDED.recorded_at, updated_at = NOW()
+                    WHERE dispatcher_last_positions.recorded_at < EXCLUDED.recorded_at`
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, insertQuery, args...); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, upsertQuery, dispatcherId, latest.Lat, latest.Lng, latest.AccuracyM, latest.Heading, latest.SpeedMps, latest.RecordedAt); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *LocationStore) GetLastPosition(ctx context.Context, dispatcherId string) (*models.DispatcherPosition, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT d.id, d.user_id, d.vehicle_type, pos.lat, pos.lng, pos.accuracy_m, pos.heading, pos.speed_mps, pos.recorded_at, 0
+              FROM dispatcher_last_positions pos JOIN dispatchers d ON d.id = pos.dispatcher_id
+              WHERE pos.dispatcher_id = $1`
+
+	position, err := scanDispatcherPosition(l.db.QueryRowContext(ctx, query, dispatcherId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPositionNotFound
+		}
+		return nil, err
+	}
+
+	return position, nil
+}
+
+// FindDispatchersWithinRadius returns active dispatchers whose last position, recorded
+// no earlier than since, is within radiusKm of center, nearest first. A bounding box
+// narrows the rows before the exact haversine distance is computed.
+func (l *LocationStore) FindDispatchersWithinRadius(ctx context.Context, center geo.Point, radiusKm float64, since time.Time) (*[]models.DispatcherPosition, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	dLat := radiusKm / kmPerDegreeLat
+	minLng, maxLng := -180.0, 180.0
+	if cosLat := math.Cos(center.Lat * math.Pi / 180); cosLat > 0.01 {
+		dLng := radiusKm / (kmPerDegreeLat * cosLat)
+		minLng, maxLng = center.Lng-dLng, center.Lng+dLng
+	}
+
+	query := `SELECT * FROM (
+                SELECT d.id, d.user_id, d.vehicle_type, pos.lat, pos.lng, pos.accuracy_m, pos.heading, pos.speed_mps, pos.recorded_at,
+                       2 * 6371 * ASIN(SQRT(POWER(SIN(RADIANS(pos.lat - $1) / 2), 2) + COS(RADIANS($1)) * COS(RADIANS(pos.lat)) * POWER(SIN(RADIANS(pos.lng - $2) / 2), 2))) AS distance_km
+                FROM dispatcher_last_positions pos JOIN dispatchers d ON d.id = pos.dispatcher_id
+                WHERE d.isactive = TRUE AND pos.recorded_at >= $3 AND pos.lat BETWEEN $4 AND $5 AND pos.lng BETWEEN $6 AND $7
+              ) nearby WHERE distance_km <= $8 ORDER BY distance_km`
+
+	positions := []models.DispatcherPosition{}
+
+	// recorded_at holds UTC wall-clock times, so since is compared in UTC too
+	rows, err := l.db.QueryContext(ctx, query, center.Lat, center.Lng, since.UTC(), center.Lat-dLat, center.Lat+dLat, minLng, maxLng, radiusKm)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		position, err := scanDispatcherPosition(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		positions = append(positions, *position)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &positions, nil
+}
+
+func scanDispatcherPosition(row rowScanner) (*models.DispatcherPosition, error) {
+	p := &models.DispatcherPosition{}
+	var accuracy, heading, speed sql.NullFloat64
+
+	if err := row.Scan(&p.DispatcherID, &p.UserID, &p.VehicleType, &p.Lat, &p.Lng, &accuracy, &heading, &speed, &p.RecordedAt, &p.DistanceKm); err != nil {
+		return nil, err
+	}
+
+	p.AccuracyM = nullFloatPtr(accuracy)
+	p.Heading = nullFloatPtr(heading)
+	p.SpeedMps = nullFloatPtr(speed)
+	return p, nil
+}
+
+func nullFloatPtr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+const (
+	LoginThrottleAccount = "account"
+	LoginThrottleIP      = "ip"
+	LoginThrottleMFA     = "mfa" // two-factor code attempts, by user ID
+)
+
+// ThrottlePolicy decides how long logins are refused after repeated failures. The
+// first FreeAttempts failures are not delayed; after that the delay doubles from
+// BaseDelay up to MaxDelay, and from LockoutAfter failures on logins are locked for
+// LockoutDuration. Failures older than Window are forgotten.
+type ThrottlePolicy struct {
+	FreeAttempts    int
+	LockoutAfter    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+// LockFor returns how long logins are refused after the given number of failures.
+func (p ThrottlePolicy) LockFor(failures int) time.Duration {
+	switch {
+	case failures >= p.LockoutAfter:
+		return p.LockoutDuration
+	case failures <= p.FreeAttempts:
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+type LoginThrottleStore struct {
+	db DB
+}
+
+const loginThrottleColumns = `kind, subject, failures, last_failure_at, locked_until, updated_at`
+
+func scanLoginThrottle(row rowScanner, throttle *models.LoginThrottle) error {
+	var lastFailureAt, lockedUntil sql.NullTime
+
+	if err := row.Scan(&throttle.Kind, &throttle.Subject, &throttle.Failures, &lastFailureAt, &lockedUntil, &throttle.UpdatedAt); err != nil {
+		return err
+	}
+
+	throttle.LastFailureAt, throttle.LockedUntil = nil, nil
+	if lastFailureAt.Valid {
+		throttle.LastFailureAt = &lastFailureAt.Time
+	}
+	if lockedUntil.Valid {
+		throttle.LockedUntil = &lockedUntil.Time
+	}
+
+	return nil
+}
+
+func (l *LoginThrottleStore) GetLoginThrottle(ctx context.Context, kind, subject string) (*models.LoginThrottle, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	throttle := &models.LoginThrottle{}
+
+	query := `SELECT ` + loginThrottleColumns + ` FROM login_throttles WHERE kind = $1 AND subject = $2`
+
+	if err := scanLoginThrottle(l.db.QueryRowContext(ctx, query, kind, subject), throttle); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLoginThrottleNotFound
+		}
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+// RecordLoginFailure counts a failed login for the subject and locks it as policy
+// says. The row is locked while it is updated, so concurrent failures on other
+// instances are all counted. Rows of the same kind whose failures have been forgotten
+// and that are no longer locked are cleared at the same time.
+func (l *LoginThrottleStore) RecordLoginFailure(ctx context.Context, kind, subject string, policy ThrottlePolicy) (*models.LoginThrottle, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO login_throttles (kind, subject) VALUES ($1, $2) ON CONFLICT (kind, subject) DO NOTHING`, kind, subject); err != nil {
+		return nil, err
+	}
+
+	throttle := &models.LoginThrottle{}
+
+	query := `SELECT ` + loginThrottleColumns + ` FROM login_throttles WHERE kind = $1 AND subject = $2 FOR UPDATE`
+
+	if err = scanLoginThrottle(tx.QueryRowContext(ctx, query, kind, subject), throttle); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	failures := throttle.Failures + 1
+	if throttle.LastFailureAt != nil && now.Sub(*throttle.LastFailureAt) > policy.Window {
+		failures = 1
+	}
+
+	var lockedUntil *time.Time
+	if lockFor := policy.LockFor(failures); lockFor > 0 {
+		until := now.Add(lockFor)
+		lockedUntil = &until
+	}
+
+	query = `UPDATE login_throttles SET failures = $3, last_failure_at = $4, locked_until = $5, updated_at = NOW()
+             WHERE kind = $1 AND subject = $2 RETURNING ` + loginThrottleColumns
+
+	if err = scanLoginThrottle(tx.QueryRowContext(ctx, query, kind, subject, failures, now, lockedUntil), throttle); err != nil {
+		return nil, err
+	}
+
+	pruneQuery := `DELETE FROM login_throttles
+                   WHERE kind = $1 AND last_failure_at < NOW() - make_interval(secs => $2)
+                     AND (locked_until IS NULL OR locked_until < NOW())`
+
+	if _, err = tx.ExecContext(ctx, pruneQuery, kind, policy.Window.Seconds()); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+// ClearLoginThrottle forgets the subject's failed logins and lifts any lock.
+func (l *LoginThrottleStore) ClearLoginThrottle(ctx context.Context, kind, subject string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM login_throttles WHERE kind = $1 AND subject = $2`, kind, subject); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
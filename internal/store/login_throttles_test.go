@@ -0,0 +1,50 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottlePolicyLockFor(t *testing.T) {
+	policy := ThrottlePolicy{
+		FreeAttempts:    3,
+		LockoutAfter:    10,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutDuration: 15 * time.Minute,
+		Window:          time.Hour,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", 0, 0},
+		{"last free attempt", 3, 0},
+		{"first delayed attempt", 4, time.Second},
+		{"delay doubles", 5, 2 * time.Second},
+		{"delay keeps doubling", 8, 16 * time.Second},
+		{"delay capped", 9, 30 * time.Second},
+		{"locked out", 10, 15 * time.Minute},
+		{"stays locked out", 25, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.LockFor(tt.failures); got != tt.want {
+				t.Errorf("LockFor(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottlePolicyLockForWithoutFreeAttempts(t *testing.T) {
+	policy := ThrottlePolicy{LockoutAfter: 3, BaseDelay: time.Second, MaxDelay: time.Minute, LockoutDuration: time.Hour}
+
+	for failures, want := range []time.Duration{0, time.Second, 2 * time.Second, time.Hour} {
+		if got := policy.LockFor(failures); got != want {
+			t.Errorf("LockFor(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
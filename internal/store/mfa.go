@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+type MFAStore struct {
+	db DB
+}
+
+const userMFAColumns = `user_id, secret_ciphertext, enabled_at, last_used_step, created_at, updated_at`
+
+func scanUserMFA(row rowScanner, mfa *models.UserMFA) error {
+	var enabledAt sql.NullTime
+	var lastUsedStep sql.NullInt64
+
+	if err := row.Scan(&mfa.UserID, &mfa.SecretCiphertext, &enabledAt, &lastUsedStep, &mfa.CreatedAt, &mfa.UpdatedAt); err != nil {
+		return err
+	}
+
+	mfa.EnabledAt = nil
+	if enabledAt.Valid {
+		mfa.EnabledAt = &enabledAt.Time
+	}
+	mfa.LastUsedStep = lastUsedStep.Int64
+
+	return nil
+}
+
+func (m *MFAStore) GetMFA(ctx context.Context, userId string) (*models.UserMFA, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	mfa := &models.UserMFA{}
+
+	query := `SELECT ` + userMFAColumns + ` FROM user_mfa WHERE user_id = $1`
+
+	if err := scanUserMFA(m.db.QueryRowContext(ctx, query, userId), mfa); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMFANotFound
+		}
+		return nil, err
+	}
+
+	return mfa, nil
+}
+
+// StartMFAEnrollment stores a new, not yet enabled, secret for the user, replacing any
+// unfinished enrollment. It returns ErrMFAAlreadyEnabled if MFA is already on.
+func (m *MFAStore) StartMFAEnrollment(ctx context.Context, userId, secretCiphertext string) (*models.UserMFA, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	mfa := &models.UserMFA{}
+
+	query := `INSERT INTO user_mfa (user_id, secret_ciphertext) VALUES ($1, $2)
+              ON CONFLICT (user_id) DO UPDATE SET secret_ciphertext = EXCLUDED.secret_ciphertext, last_used_step = NULL, created_at = NOW(), updated_at = NOW()
+              WHERE user_mfa.enabled_at IS NULL
+              RETURNING ` + userMFAColumns
+
+	if err = scanUserMFA(tx.QueryRowContext(ctx, query, userId, secretCiphertext), mfa); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return mfa, nil
+}
+
+// EnableMFA confirms the user's pending enrollment, recording step as the last used
+// TOTP step, and replaces their recovery codes with codeHashes.
+func (m *MFAStore) EnableMFA(ctx context.Context, userId string, step int64, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE user_mfa SET enabled_at = NOW(), last_used_step = $2, updated_at = NOW() WHERE user_id = $1 AND enabled_at IS NULL`, userId, step)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep records step as used. It returns ErrMFACodeReused if a code from the same
+// or a later step was already accepted.
+func (m *MFAStore) UseTOTPStep(ctx context.Context, userId string, step int64) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE user_mfa SET last_used_step = $2, updated_at = NOW()
+              WHERE user_id = $1 AND enabled_at IS NOT NULL AND (last_used_step IS NULL OR last_used_step < $2)`
+
+	res, err := tx.ExecContext(ctx, query, userId, step)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrMFACodeReused
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks the user's unused recovery code with the given hash as used. It
+// returns ErrMFARecoveryCodeInvalid if there is none.
+func (m *MFAStore) UseRecoveryCode(ctx context.Context, userId, codeHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userId, codeHash)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrMFARecoveryCodeInvalid
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores codeHashes instead.
+func (m *MFAStore) ReplaceRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = replaceRecoveryCodes(ctx, tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx Tx, userId string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`
+
+	_, err := tx.ExecContext(ctx, query, userId, pq.Array(codeHashes))
+	return err
+}
+
+// DisableMFA removes the user's authenticator and recovery codes.
+func (m *MFAStore) DisableMFA(ctx context.Context, userId string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrMFANotFound
+	}
+
+	return tx.Commit()
+}
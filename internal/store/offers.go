@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+const offerColumns = `id, package_id, dispatcher_id, status, expires_at, responded_at, created_at`
+
+func scanOffer(row rowScanner) (*models.DispatcherOffer, error) {
+	offer := &models.DispatcherOffer{}
+	var respondedAt sql.NullTime
+
+	if err := row.Scan(&offer.ID, &offer.PackageID, &offer.DispatcherID, &offer.Status, &offer.ExpiresAt, &respondedAt, &offer.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if respondedAt.Valid {
+		offer.RespondedAt = &respondedAt.Time
+	}
+	return offer, nil
+}
+
+// lockPendingOffer locks a dispatcher's offer and checks it can still be answered.
+func lockPendingOffer(ctx context.Context, tx Tx, offerId, dispatcherId string) (*models.DispatcherOffer, error) {
+	query := `SELECT ` + offerColumns + ` FROM dispatcher_offers WHERE id = $1 AND dispatcher_id = $2 FOR UPDATE`
+
+	offer, err := scanOffer(tx.QueryRowContext(ctx, query, offerId, dispatcherId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOfferNotFound
+		}
+		return nil, err
+	}
+
+	if offer.Status != "pending" {
+		return nil, ErrOfferClosed
+	}
+
+	if time.Now().After(offer.ExpiresAt) {
+		return nil, ErrOfferExpired
+	}
+
+	return offer, nil
+}
+
+// AcceptOffer assigns the offered package to the dispatcher and moves it to awaiting_pickup.
+func (a *AssignmentStore) AcceptOffer(ctx context.Context, offerId, dispatcherId, actorId string) (*models.Package, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	offer, err := lockPendingOffer(ctx, tx, offerId, dispatcherId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE dispatcher_offers SET status = 'accepted', responded_at = NOW() WHERE id = $1`, offer.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE packages SET dispatcher_id = $1 WHERE id = $2`, dispatcherId, offer.PackageID); err != nil {
+		return nil, err
+	}
+
+	pack, err := transitionPackage(ctx, tx, offer.PackageID, &models.PackageStatusEvent{
+		ToStatus: PackageStatusAwaitingPickup,
+		ActorID:  actorId,
+		Note:     "offer accepted by dispatcher",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+func (a *AssignmentStore) DeclineOffer(ctx context.Context, offerId, dispatcherId string) (*models.DispatcherOffer, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = lockPendingOffer(ctx, tx, offerId, dispatcherId); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE dispatcher_offers SET status = 'declined', responded_at = NOW() WHERE id = $1 RETURNING ` + offerColumns
+
+	offer, err := scanOffer(tx.QueryRowContext(ctx, query, offerId))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+// ExpireOffers marks up to limit overdue pending offers as expired and returns the
+// IDs of their packages so they can be offered to the next candidate. Rows locked by
+// another instance are skipped.
+func (a *AssignmentStore) ExpireOffers(ctx context.Context, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `UPDATE dispatcher_offers SET status = 'expired', responded_at = NOW()
+              WHERE id IN (SELECT id FROM dispatcher_offers WHERE status = 'pending' AND expires_at < NOW() ORDER BY expires_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+              RETURNING package_id`
+
+	return a.queryIds(ctx, query, limit)
+}
+
+// GetUnofferedPackageIds returns up to limit packages that still need a dispatcher and
+// have no pending offer. Packages never attempted come first, then the ones attempted
+// longest ago, so packages no dispatcher can take do not hold up the rest.
+func (a *AssignmentStore) GetUnofferedPackageIds(ctx context.Context, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT p.id FROM packages p
+              WHERE p.status = 'created' AND p.dispatcher_id IS NULL
+                AND NOT EXISTS (SELECT 1 FROM dispatcher_offers o WHERE o.package_id = p.id AND o.status = 'pending')
+              ORDER BY p.last_offer_attempt_at NULLS FIRST, p.created_at LIMIT $1`
+
+	return a.queryIds(ctx, query, limit)
+}
+
+func (a *AssignmentStore) queryIds(ctx context.Context, query string, args ...any) ([]string, error) {
+	ids := []string{}
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetOffersByDispatcherId lists a dispatcher's offers, newest first. An empty status
+// returns offers in every status.
+func (a *AssignmentStore) GetOffersByDispatcherId(ctx context.Context, dispatcherId, status string) (*[]models.DispatcherOffer, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT ` + offerColumns + ` FROM dispatcher_offers WHERE dispatcher_id = $1 AND ($2 = '' OR status = $2) ORDER BY created_at DESC`
+
+	offers := []models.DispatcherOffer{}
+
+	rows, err := a.db.QueryContext(ctx, query, dispatcherId, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		offers = append(offers, *offer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &offers, nil
+}
+
+func (a *AssignmentStore) GetOfferStats(ctx context.Context, dispatcherId string) (*models.OfferStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	stats := &models.OfferStats{}
+
+	query := `SELECT COUNT(*),
+                     COUNT(*) FILTER (WHERE status = 'pending'),
+                     COUNT(*) FILTER (WHERE status = 'accepted'),
+                     COUNT(*) FILTER (WHERE status = 'declined'),
+                     COUNT(*) FILTER (WHERE status = 'expired')
+              FROM dispatcher_offers WHERE dispatcher_id = $1`
+
+	if err := a.db.QueryRowContext(ctx, query, dispatcherId).Scan(&stats.Total, &stats.Pending, &stats.Accepted, &stats.Declined, &stats.Expired); err != nil {
+		return nil, err
+	}
+
+	if answered := stats.Accepted + stats.Declined + stats.Expired; answered > 0 {
+		stats.AcceptanceRate = float64(stats.Accepted) / float64(answered)
+	}
+
+	return stats, nil
+}
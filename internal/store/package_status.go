@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	PackageStatusCreated        = "created"
+	PackageStatusAwaitingPickup = "awaiting_pickup"
+	PackageStatusPickedUp       = "picked_up"
+	PackageStatusInTransit      = "in_transit"
+	PackageStatusOutForDelivery = "out_for_delivery"
+	PackageStatusDelivered      = "delivered"
+	PackageStatusFailed         = "failed"
+	PackageStatusCancelled      = "cancelled"
+	PackageStatusReturned       = "returned"
+)
+
+// packageTransitions lists the statuses a package may move to from each status.
+// Delivered, cancelled and returned are terminal.
+var packageTransitions = map[string][]string{
+	PackageStatusCreated:        {PackageStatusAwaitingPickup, PackageStatusCancelled},
+	PackageStatusAwaitingPickup: {PackageStatusPickedUp, PackageStatusFailed, PackageStatusCancelled},
+	PackageStatusPickedUp:       {PackageStatusInTransit, PackageStatusFailed, PackageStatusReturned},
+	PackageStatusInTransit:      {PackageStatusOutForDelivery, PackageStatusFailed, PackageStatusReturned},
+	PackageStatusOutForDelivery: {PackageStatusDelivered, PackageStatusFailed, PackageStatusReturned},
+	PackageStatusFailed:         {PackageStatusAwaitingPickup, PackageStatusReturned},
+	PackageStatusDelivered:      {},
+	PackageStatusCancelled:      {},
+	PackageStatusReturned:       {},
+}
+
+// IsValidPackageStatus reports whether status is part of the package lifecycle.
+func IsValidPackageStatus(status string) bool {
+	_, ok := packageTransitions[status]
+	return ok
+}
+
+// IsTerminalPackageStatus reports whether a package in status can no longer change.
+func IsTerminalPackageStatus(status string) bool {
+	next, ok := packageTransitions[status]
+	return ok && len(next) == 0
+}
+
+// CanTransitionPackage reports whether a package may move from one status to another.
+func CanTransitionPackage(from, to string) bool {
+	return slices.Contains(packageTransitions[from], to)
+}
+
+// InvalidTransitionError is returned when a package status change is not allowed
+// by the lifecycle.
+type InvalidTransitionError struct {
+	From, To string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("invalid package status transition from %q to %q", e.From, e.To)
+}
@@ -1,15 +1,381 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	"github.com/puremike/pcourierds/internal/models"
+	"github.com/puremike/pcourierds/internal/tracking"
 )
 
 type PackageStore struct {
-	db *sql.DB
+	db DB
 }
 
-func (p *PackageStore) CreatePackage(pack *models.Package) error {
+const packageColumns = `id, user_id, dispatcher_id, tracking_code, origin, destination, recipient_name, recipient_phone, recipient_email, description, weight_kg, length_cm, width_cm, height_cm, vehicle_type, origin_lat, origin_lng, destination_lat, destination_lng, price, currency, status, estimated_delivery_at, created_at, updated_at`
+
+// maxTrackingCodeAttempts bounds how many tracking codes are tried when a generated
+// code collides with an existing one.
+const maxTrackingCodeAttempts = 5
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPackage(row rowScanner, pack *models.Package) error {
+	var dispatcherId, trackingCode sql.NullString
+	var estimatedDeliveryAt sql.NullTime
+
+	if err := row.Scan(&pack.ID, &pack.UserID, &dispatcherId, &trackingCode, &pack.Origin, &pack.Destination, &pack.RecipientName, &pack.RecipientPhone, &pack.RecipientEmail, &pack.Description, &pack.WeightKg, &pack.LengthCm, &pack.WidthCm, &pack.HeightCm, &pack.VehicleType, &pack.OriginLat, &pack.OriginLng, &pack.DestinationLat, &pack.DestinationLng, &pack.Price, &pack.Currency, &pack.Status, &estimatedDeliveryAt, &pack.CreatedAt, &pack.UpdatedAt); err != nil {
+		return err
+	}
+
+	pack.DispatcherID = dispatcherId.String
+	pack.TrackingCode = trackingCode.String
+	pack.EstimatedDeliveryAt = nil
+	if estimatedDeliveryAt.Valid {
+		pack.EstimatedDeliveryAt = &estimatedDeliveryAt.Time
+	}
 	return nil
 }
+
+// CreatePackage stores a new package with a freshly generated tracking code, retrying
+// with another code if it collides with an existing one.
+func (p *PackageStore) CreatePackage(ctx context.Context, pack *models.Package) (*models.Package, error) {
+	for attempt := 1; ; attempt++ {
+		code, err := tracking.NewCode()
+		if err != nil {
+			return nil, err
+		}
+
+		pack.TrackingCode = code
+
+		err = p.insertPackage(ctx, pack)
+		if err == nil {
+			return pack, nil
+		}
+
+		if !isUniqueViolation(err, "packages_tracking_code_key") || attempt == maxTrackingCodeAttempts {
+			return nil, err
+		}
+	}
+}
+
+func (p *PackageStore) insertPackage(ctx context.Context, pack *models.Package) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `INSERT INTO packages (user_id, tracking_code, origin, destination, recipient_name, recipient_phone, recipient_email, description, weight_kg, length_cm, width_cm, height_cm, vehicle_type, origin_lat, origin_lng, destination_lat, destination_lng, price, currency, status, delivery_pin_hash)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING ` + packageColumns
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = scanPackage(tx.QueryRowContext(ctx, query,
+		pack.UserID,
+		pack.TrackingCode,
+		pack.Origin,
+		pack.Destination,
+		pack.RecipientName,
+		pack.RecipientPhone,
+		pack.RecipientEmail,
+		pack.Description,
+		pack.WeightKg,
+		pack.LengthCm,
+		pack.WidthCm,
+		pack.HeightCm,
+		pack.VehicleType,
+		pack.OriginLat,
+		pack.OriginLng,
+		pack.DestinationLat,
+		pack.DestinationLng,
+		pack.Price,
+		pack.Currency,
+		PackageStatusCreated,
+		nullString(pack.DeliveryPinHash),
+	), pack); err != nil {
+		return err
+	}
+
+	event := &models.PackageStatusEvent{
+		PackageID: pack.ID,
+		ToStatus:  PackageStatusCreated,
+		ActorID:   pack.UserID,
+	}
+
+	if err = insertPackageStatusEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (p *PackageStore) GetPackageById(ctx context.Context, id string) (*models.Package, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	pack := &models.Package{}
+
+	query := `SELECT ` + packageColumns + ` FROM packages WHERE id = $1`
+
+	if err := scanPackage(p.db.QueryRowContext(ctx, query, id), pack); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+func (p *PackageStore) GetPackageByTrackingCode(ctx context.Context, code string) (*models.Package, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	pack := &models.Package{}
+
+	query := `SELECT ` + packageColumns + ` FROM packages WHERE tracking_code = $1`
+
+	if err := scanPackage(p.db.QueryRowContext(ctx, query, code), pack); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+func (p *PackageStore) GetPackagesByUserId(ctx context.Context, userId string) (*[]models.Package, error) {
+	query := `SELECT ` + packageColumns + ` FROM packages WHERE user_id = $1 ORDER BY created_at DESC`
+
+	return p.listPackages(ctx, query, userId)
+}
+
+func (p *PackageStore) GetPackagesByDispatcherId(ctx context.Context, dispatcherId string) (*[]models.Package, error) {
+	query := `SELECT ` + packageColumns + ` FROM packages WHERE dispatcher_id = $1 ORDER BY created_at DESC`
+
+	return p.listPackages(ctx, query, dispatcherId)
+}
+
+func (p *PackageStore) listPackages(ctx context.Context, query string, args ...any) (*[]models.Package, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	packages := []models.Package{}
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pack models.Package
+		if err = scanPackage(rows, &pack); err != nil {
+			return nil, err
+		}
+
+		packages = append(packages, pack)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &packages, nil
+}
+
+// UpdatePackage updates the recipient and description of a package that has not been
+// picked up yet. The addresses are fixed by the quote the package was booked with. It
+// returns ErrPackageNotEditable if the package has already moved past created.
+func (p *PackageStore) UpdatePackage(ctx context.Context, pack *models.Package, id string) (*models.Package, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `UPDATE packages SET recipient_name = $1, recipient_phone = $2, description = $3, updated_at = NOW() WHERE id = $4 AND status = $5 RETURNING ` + packageColumns
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if err = scanPackage(tx.QueryRowContext(ctx, query, pack.RecipientName, pack.RecipientPhone, pack.Description, id, PackageStatusCreated), pack); err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		// nothing was updated, find out why
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM packages WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrPackageNotFound
+		}
+		return nil, ErrPackageNotEditable
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+// UpdatePackageStatus moves a package to event.ToStatus and records the change in its
+// status history. It returns an *InvalidTransitionError if the lifecycle does not allow it.
+// Packages can only be delivered through ConfirmDelivery.
+func (p *PackageStore) UpdatePackageStatus(ctx context.Context, id string, event *models.PackageStatusEvent) (*models.Package, error) {
+	if event.ToStatus == PackageStatusDelivered {
+		return nil, ErrProofOfDeliveryRequired
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	pack, err := transitionPackage(ctx, tx, id, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+// transitionPackage locks the package row, validates the transition, updates the status
+// and appends the status event, all within tx.
+func transitionPackage(ctx context.Context, tx Tx, id string, event *models.PackageStatusEvent) (*models.Package, error) {
+	var currentStatus string
+
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM packages WHERE id = $1 FOR UPDATE`, id).Scan(&currentStatus); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+
+	if !CanTransitionPackage(currentStatus, event.ToStatus) {
+		return nil, &InvalidTransitionError{From: currentStatus, To: event.ToStatus}
+	}
+
+	pack := &models.Package{}
+
+	query := `UPDATE packages SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING ` + packageColumns
+
+	if err := scanPackage(tx.QueryRowContext(ctx, query, event.ToStatus, id), pack); err != nil {
+		return nil, err
+	}
+
+	// a cancelled package must not stay on offer to a dispatcher
+	if event.ToStatus == PackageStatusCancelled {
+		if _, err := tx.ExecContext(ctx, `UPDATE dispatcher_offers SET status = 'cancelled', responded_at = NOW() WHERE package_id = $1 AND status = 'pending'`, id); err != nil {
+			return nil, err
+		}
+	}
+
+	event.PackageID = id
+	event.FromStatus = currentStatus
+
+	if err := insertPackageStatusEvent(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+func insertPackageStatusEvent(ctx context.Context, tx Tx, event *models.PackageStatusEvent) error {
+	query := `INSERT INTO package_status_events (package_id, from_status, to_status, actor_id, location, note) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+
+	fromStatus := sql.NullString{String: event.FromStatus, Valid: event.FromStatus != ""}
+	actorId := sql.NullString{String: event.ActorID, Valid: event.ActorID != ""}
+
+	return tx.QueryRowContext(ctx, query, event.PackageID, fromStatus, event.ToStatus, actorId, event.Location, event.Note).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (p *PackageStore) UpdateEstimatedDelivery(ctx context.Context, id string, eta time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `UPDATE packages SET estimated_delivery_at = $1, updated_at = NOW() WHERE id = $2`
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, eta, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPackageNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PackageStore) CancelPackage(ctx context.Context, id, actorId, note string) (*models.Package, error) {
+	return p.UpdatePackageStatus(ctx, id, &models.PackageStatusEvent{
+		ToStatus: PackageStatusCancelled,
+		ActorID:  actorId,
+		Note:     note,
+	})
+}
+
+func (p *PackageStore) GetPackageStatusEvents(ctx context.Context, packageId string) (*[]models.PackageStatusEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT id, package_id, from_status, to_status, actor_id, location, note, created_at FROM package_status_events WHERE package_id = $1 ORDER BY created_at, id`
+
+	events := []models.PackageStatusEvent{}
+
+	rows, err := p.db.QueryContext(ctx, query, packageId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.PackageStatusEvent
+		var fromStatus, actorId sql.NullString
+		if err = rows.Scan(&e.ID, &e.PackageID, &fromStatus, &e.ToStatus, &actorId, &e.Location, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		e.FromStatus = fromStatus.String
+		e.ActorID = actorId.String
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
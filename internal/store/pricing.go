@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+type PricingStore struct {
+	db DB
+}
+
+func (p *PricingStore) GetRate(ctx context.Context, vehicleType string) (*models.PricingRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	rate := &models.PricingRate{}
+
+	query := `SELECT vehicle_type, currency, base_fare, per_km, per_kg, min_fare, volumetric_divisor, updated_at FROM pricing_rates WHERE vehicle_type = $1`
+
+	if err := p.db.QueryRowContext(ctx, query, vehicleType).Scan(&rate.VehicleType, &rate.Currency, &rate.BaseFare, &rate.PerKm, &rate.PerKg, &rate.MinFare, &rate.VolumetricDivisor, &rate.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPricingRateNotFound
+		}
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (p *PricingStore) GetAllRates(ctx context.Context) (*[]models.PricingRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT vehicle_type, currency, base_fare, per_km, per_kg, min_fare, volumetric_divisor, updated_at FROM pricing_rates ORDER BY vehicle_type`
+
+	rates := []models.PricingRate{}
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.PricingRate
+		if err = rows.Scan(&r.VehicleType, &r.Currency, &r.BaseFare, &r.PerKm, &r.PerKg, &r.MinFare, &r.VolumetricDivisor, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &rates, nil
+}
+
+func (p *PricingStore) UpsertRate(ctx context.Context, rate *models.PricingRate) (*models.PricingRate, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `INSERT INTO pricing_rates (vehicle_type, currency, base_fare, per_km, per_kg, min_fare, volumetric_divisor) VALUES ($1, $2, $3, $4, $5, $6, $7)
+              ON CONFLICT (vehicle_type) DO UPDATE SET currency = EXCLUDED.currency, base_fare = EXCLUDED.base_fare, per_km = EXCLUDED.per_km, per_kg = EXCLUDED.per_kg, min_fare = EXCLUDED.min_fare, volumetric_divisor = EXCLUDED.volumetric_divisor, updated_at = NOW()
+              RETURNING vehicle_type, currency, base_fare, per_km, per_kg, min_fare, volumetric_divisor, updated_at`
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if err = tx.QueryRowContext(ctx, query, rate.VehicleType, rate.Currency, rate.BaseFare, rate.PerKm, rate.PerKg, rate.MinFare, rate.VolumetricDivisor).Scan(&rate.VehicleType, &rate.Currency, &rate.BaseFare, &rate.PerKm, &rate.PerKg, &rate.MinFare, &rate.VolumetricDivisor, &rate.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (p *PricingStore) GetWeightTiers(ctx context.Context, vehicleType string) (*[]models.WeightTier, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT id, vehicle_type, min_weight_kg, max_weight_kg, surcharge FROM pricing_weight_tiers WHERE vehicle_type = $1 ORDER BY min_weight_kg`
+
+	tiers := []models.WeightTier{}
+
+	rows, err := p.db.QueryContext(ctx, query, vehicleType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.WeightTier
+		if err = rows.Scan(&t.ID, &t.VehicleType, &t.MinWeightKg, &t.MaxWeightKg, &t.Surcharge); err != nil {
+			return nil, err
+		}
+
+		tiers = append(tiers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &tiers, nil
+}
+
+// ReplaceWeightTiers swaps all weight tiers of a vehicle type in one transaction so
+// quotes never see a partially edited tier table.
+func (p *PricingStore) ReplaceWeightTiers(ctx context.Context, vehicleType string, tiers []models.WeightTier) (*[]models.WeightTier, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pricing_rates WHERE vehicle_type = $1)`, vehicleType).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPricingRateNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM pricing_weight_tiers WHERE vehicle_type = $1`, vehicleType); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO pricing_weight_tiers (vehicle_type, min_weight_kg, max_weight_kg, surcharge) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	saved := []models.WeightTier{}
+	for _, t := range tiers {
+		t.VehicleType = vehicleType
+		if err = tx.QueryRowContext(ctx, query, vehicleType, t.MinWeightKg, t.MaxWeightKg, t.Surcharge).Scan(&t.ID); err != nil {
+			return nil, err
+		}
+		saved = append(saved, t)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
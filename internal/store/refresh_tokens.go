@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+type RefreshTokenStore struct {
+	db DB
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, replaced_by, mfa_verified, created_at`
+
+func scanRefreshToken(row rowScanner, token *models.RefreshToken) error {
+	var usedAt, revokedAt sql.NullTime
+	var replacedBy sql.NullString
+
+	if err := row.Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &usedAt, &revokedAt, &replacedBy, &token.MFAVerified, &token.CreatedAt); err != nil {
+		return err
+	}
+
+	token.UsedAt, token.RevokedAt = nil, nil
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	token.ReplacedBy = replacedBy.String
+
+	return nil
+}
+
+// CreateRefreshToken stores a refresh token. A token without a FamilyID starts a new
+// family.
+func (r *RefreshTokenStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = insertRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertRefreshToken(ctx context.Context, tx Tx, token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, mfa_verified) VALUES ($1, COALESCE($2::uuid, gen_random_uuid()), $3, $4, $5)
+              RETURNING ` + refreshTokenColumns
+
+	return scanRefreshToken(tx.QueryRowContext(ctx, query, token.UserID, nullString(token.FamilyID), token.TokenHash, token.ExpiresAt, token.MFAVerified), token)
+}
+
+// RotateRefreshToken exchanges the token with the given hash for next, which joins the
+// same family and keeps its MFA status. Presenting a token that was already exchanged means it was copied, so
+// the whole family is revoked and ErrRefreshTokenReused returned.
+func (r *RefreshTokenStore) RotateRefreshToken(ctx context.Context, hash string, next *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	current := &models.RefreshToken{}
+
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	if err = scanRefreshToken(tx.QueryRowContext(ctx, query, hash), current); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	switch {
+	case current.RevokedAt != nil:
+		return ErrRefreshTokenInvalid
+	case current.UsedAt != nil:
+		if _, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`, current.FamilyID); err != nil {
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	case time.Now().After(current.ExpiresAt):
+		return ErrRefreshTokenExpired
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	next.MFAVerified = current.MFAVerified
+
+	if err = insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW(), replaced_by = $1 WHERE id = $2`, next.ID, current.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily revokes the family of the user's refresh token with the given
+// hash, ending the login it was issued from.
+func (r *RefreshTokenStore) RevokeRefreshTokenFamily(ctx context.Context, hash, userId string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE refresh_tokens SET revoked_at = NOW()
+              WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2) AND revoked_at IS NULL`
+
+	if _, err = tx.ExecContext(ctx, query, hash, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type RevokedTokenStore struct {
+	db DB
+}
+
+// RevokeToken denylists an access token until it expires. Entries of tokens that have
+// expired since are cleared at the same time.
+func (r *RevokedTokenStore) RevokeToken(ctx context.Context, jti, userId string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`, jti, userId, expiresAt); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < $1`, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *RevokedTokenStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	var revoked bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti).Scan(&revoked); err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// RevokeAllUserTokens rejects every access token issued to the user until now and
+// revokes all of their refresh tokens and API keys.
+func (r *RevokedTokenStore) RevokeAllUserTokens(ctx context.Context, userId string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET tokens_valid_after = NOW() WHERE id = $1`, userId)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrUserNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userId); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
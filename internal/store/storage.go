@@ -6,6 +6,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/puremike/pcourierds/internal/geo"
+	"github.com/puremike/pcourierds/internal/listing"
 	"github.com/puremike/pcourierds/internal/models"
 )
 
@@ -15,39 +17,169 @@ type UsersRepository interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User, id string) (*models.User, error)
 	UpdatePassword(ctx context.Context, user *models.User, id string) error
-	GetAllUsers(ctx context.Context) (*[]models.User, error)
+	MarkEmailVerified(ctx context.Context, id string) error
+	GetAllUsers(ctx context.Context, q *listing.Query) (*[]models.User, *listing.Page, error)
 	DeleteUserById(ctx context.Context, id string) error
 }
 
+type RefreshTokensRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, hash string, next *models.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, hash, userId string) error
+}
+
+type UserTokensRepository interface {
+	CreateUserToken(ctx context.Context, token *models.UserToken) error
+	ConsumeUserToken(ctx context.Context, purpose, hash string) (*models.UserToken, error)
+}
+
+type APIKeysRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userId string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id, userId string) error
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+type LoginThrottlesRepository interface {
+	GetLoginThrottle(ctx context.Context, kind, subject string) (*models.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, kind, subject string, policy ThrottlePolicy) (*models.LoginThrottle, error)
+	ClearLoginThrottle(ctx context.Context, kind, subject string) error
+}
+
+type MFARepository interface {
+	GetMFA(ctx context.Context, userId string) (*models.UserMFA, error)
+	StartMFAEnrollment(ctx context.Context, userId, secretCiphertext string) (*models.UserMFA, error)
+	EnableMFA(ctx context.Context, userId string, step int64, codeHashes []string) error
+	UseTOTPStep(ctx context.Context, userId string, step int64) error
+	UseRecoveryCode(ctx context.Context, userId, codeHash string) error
+	ReplaceRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error
+	DisableMFA(ctx context.Context, userId string) error
+}
+
+type RevokedTokensRepository interface {
+	RevokeToken(ctx context.Context, jti, userId string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeAllUserTokens(ctx context.Context, userId string) error
+}
+
 type DispatchersApplyRepository interface {
 	DispatcherApplication(ctx context.Context, application *models.DispatcherApplication) (*models.DispatcherApplication, error)
-	GetAllApplications(ctx context.Context) (*[]models.DispatcherApplication, error)
+	GetAllApplications(ctx context.Context, q *listing.Query) (*[]models.DispatcherApplication, *listing.Page, error)
 	GetApplicationById(ctx context.Context, id string) (*models.DispatcherApplication, error)
 	GetApplicationByUserId(ctx context.Context, userId string) (*models.DispatcherApplication, error)
-	DeleteApplicationByUserId(ctx context.Context, userId string) error
-	UpdateDispatchApplicationStatus(ctx context.Context, dispatch *models.DispatcherApplication, id string) error
+	ReviewApplication(ctx context.Context, dispatch *models.DispatcherApplication) error
+	UpdateApplication(ctx context.Context, application *models.DispatcherApplication, actorId string) error
+	WithdrawApplication(ctx context.Context, id, actorId, note string) (*models.DispatcherApplication, error)
+	GetApplicationHistory(ctx context.Context, applicationId string) (*[]models.ApplicationHistoryEntry, error)
+	SaveDocument(ctx context.Context, doc *models.ApplicationDocument) (string, error)
+	GetDocumentsByApplicationId(ctx context.Context, applicationId string) (*[]models.ApplicationDocument, error)
+	GetDocumentById(ctx context.Context, id string) (*models.ApplicationDocument, error)
+	GetDuplicateIdentifiers(ctx context.Context) (*[]models.DuplicateIdentifier, error)
+}
+
+type ApplicationRulesRepository interface {
+	GetRule(ctx context.Context, region string) (*models.ApplicationRule, error)
+	GetAllRules(ctx context.Context) (*[]models.ApplicationRule, error)
+	UpsertRule(ctx context.Context, rule *models.ApplicationRule) (*models.ApplicationRule, error)
+	DeleteRule(ctx context.Context, region string) error
+}
+
+type VehicleTypesRepository interface {
+	GetVehicleType(ctx context.Context, code string) (*models.VehicleType, error)
+	GetAllVehicleTypes(ctx context.Context) (*[]models.VehicleType, error)
+	UpsertVehicleType(ctx context.Context, vehicle *models.VehicleType) (*models.VehicleType, error)
+	DeleteVehicleType(ctx context.Context, code string) error
 }
 
 type DispatchersRepository interface {
 	CreateDispatcher(ctx context.Context, dispatcher *models.Dispatcher) error
+	GetDispatcherByUserId(ctx context.Context, userId string) (*models.Dispatcher, error)
 }
 
 type PackagesRepository interface {
+	CreatePackage(ctx context.Context, pack *models.Package) (*models.Package, error)
+	GetPackageById(ctx context.Context, id string) (*models.Package, error)
+	GetPackageByTrackingCode(ctx context.Context, code string) (*models.Package, error)
+	GetPackagesByUserId(ctx context.Context, userId string) (*[]models.Package, error)
+	GetPackagesByDispatcherId(ctx context.Context, dispatcherId string) (*[]models.Package, error)
+	UpdatePackage(ctx context.Context, pack *models.Package, id string) (*models.Package, error)
+	UpdatePackageStatus(ctx context.Context, id string, event *models.PackageStatusEvent) (*models.Package, error)
+	UpdateEstimatedDelivery(ctx context.Context, id string, eta time.Time) error
+	CancelPackage(ctx context.Context, id, actorId, note string) (*models.Package, error)
+	GetPackageStatusEvents(ctx context.Context, packageId string) (*[]models.PackageStatusEvent, error)
+	ReserveDeliveryPinAttempt(ctx context.Context, id string, maxAttempts int) (string, int, error)
+	ResetDeliveryPinAttempts(ctx context.Context, id string) error
+	ConfirmDelivery(ctx context.Context, proof *models.DeliveryProof, event *models.PackageStatusEvent) (*models.Package, error)
+	GetDeliveryProof(ctx context.Context, packageId string) (*models.DeliveryProof, error)
+}
+
+type PricingRepository interface {
+	GetRate(ctx context.Context, vehicleType string) (*models.PricingRate, error)
+	GetAllRates(ctx context.Context) (*[]models.PricingRate, error)
+	UpsertRate(ctx context.Context, rate *models.PricingRate) (*models.PricingRate, error)
+	GetWeightTiers(ctx context.Context, vehicleType string) (*[]models.WeightTier, error)
+	ReplaceWeightTiers(ctx context.Context, vehicleType string, tiers []models.WeightTier) (*[]models.WeightTier, error)
+}
+
+type AssignmentsRepository interface {
+	OfferPackage(ctx context.Context, packageId string, ttl, reofferAfter time.Duration) (*models.DispatcherOffer, error)
+	AcceptOffer(ctx context.Context, offerId, dispatcherId, actorId string) (*models.Package, error)
+	DeclineOffer(ctx context.Context, offerId, dispatcherId string) (*models.DispatcherOffer, error)
+	ExpireOffers(ctx context.Context, limit int) ([]string, error)
+	GetUnofferedPackageIds(ctx context.Context, limit int) ([]string, error)
+	GetOffersByDispatcherId(ctx context.Context, dispatcherId, status string) (*[]models.DispatcherOffer, error)
+	GetOfferStats(ctx context.Context, dispatcherId string) (*models.OfferStats, error)
+}
+
+type LocationsRepository interface {
+	RecordPings(ctx context.Context, dispatcherId string, pings []models.LocationPing) error
+	GetLastPosition(ctx context.Context, dispatcherId string) (*models.DispatcherPosition, error)
+	FindDispatchersWithinRadius(ctx context.Context, center geo.Point, radiusKm float64, since time.Time) (*[]models.DispatcherPosition, error)
 }
 
 type Storage struct {
 	Users                  UsersRepository
+	RefreshTokens          RefreshTokensRepository
+	RevokedTokens          RevokedTokensRepository
+	UserTokens             UserTokensRepository
+	MFA                    MFARepository
+	LoginThrottles         LoginThrottlesRepository
+	APIKeys                APIKeysRepository
 	DispatcherApplications DispatchersApplyRepository
+	ApplicationRules       ApplicationRulesRepository
+	VehicleTypes           VehicleTypesRepository
 	Dispatchers            DispatchersRepository
 	Packages               PackagesRepository
+	Pricing                PricingRepository
+	Assignments            AssignmentsRepository
+	Locations              LocationsRepository
+
+	db DB
 }
 
 func NewStorage(db *sql.DB) *Storage {
+	return newStorage(poolDB{db})
+}
+
+func newStorage(db DB) *Storage {
 	return &Storage{
 		Users:                  &UserStore{db},
+		RefreshTokens:          &RefreshTokenStore{db},
+		RevokedTokens:          &RevokedTokenStore{db},
+		UserTokens:             &UserTokenStore{db},
+		MFA:                    &MFAStore{db},
+		LoginThrottles:         &LoginThrottleStore{db},
+		APIKeys:                &APIKeyStore{db},
 		DispatcherApplications: &DispatcherApplyStore{db},
+		ApplicationRules:       &ApplicationRuleStore{db},
+		VehicleTypes:           &VehicleTypeStore{db},
 		Dispatchers:            &DispatcherStore{db},
 		Packages:               &PackageStore{db},
+		Pricing:                &PricingStore{db},
+		Assignments:            &AssignmentStore{db},
+		Locations:              &LocationStore{db},
+		db:                     db,
 	}
 }
 
@@ -55,4 +187,36 @@ var (
 	QueryBackgroundTimeout           = 5 * time.Second
 	ErrUserNotFound                  = errors.New("user not found")
 	ErrDispatcherApplicationNotFound = errors.New("dispatcher application not found")
+	ErrDispatcherNotFound            = errors.New("dispatcher not found")
+	ErrPackageNotFound               = errors.New("package not found")
+	ErrPackageNotEditable            = errors.New("package can no longer be updated")
+	ErrPricingRateNotFound           = errors.New("pricing rate not found")
+	ErrNoDispatcherAvailable         = errors.New("no dispatcher available")
+	ErrPackageAlreadyAssigned        = errors.New("package already assigned")
+	ErrPositionNotFound              = errors.New("dispatcher position not found")
+	ErrOfferNotFound                 = errors.New("offer not found")
+	ErrOfferPending                  = errors.New("package already has a pending offer")
+	ErrOfferClosed                   = errors.New("offer has already been answered")
+	ErrOfferExpired                  = errors.New("offer has expired")
+	ErrProofOfDeliveryRequired       = errors.New("proof of delivery required")
+	ErrDeliveryPinNotSet             = errors.New("package has no delivery pin")
+	ErrDeliveryPinLocked             = errors.New("too many wrong delivery pins")
+	ErrDeliveryProofNotFound         = errors.New("delivery proof not found")
+	ErrApplicationExists             = errors.New("user already has an open or approved application")
+	ErrApplicationAlreadyReviewed    = errors.New("dispatcher application has already been reviewed")
+	ErrApplicationNotPending         = errors.New("dispatcher application is no longer pending")
+	ErrApplicationRuleNotFound       = errors.New("application rule not found")
+	ErrDocumentNotFound              = errors.New("application document not found")
+	ErrVehicleTypeNotFound           = errors.New("vehicle type not found")
+	ErrVehicleTypeInUse              = errors.New("vehicle type is still in use")
+	ErrRefreshTokenInvalid           = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired           = errors.New("refresh token has expired")
+	ErrRefreshTokenReused            = errors.New("refresh token has already been used")
+	ErrUserTokenInvalid              = errors.New("invalid or expired token")
+	ErrMFANotFound                   = errors.New("two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled             = errors.New("two-factor authentication is already enabled")
+	ErrMFACodeReused                 = errors.New("two-factor code has already been used")
+	ErrMFARecoveryCodeInvalid        = errors.New("invalid recovery code")
+	ErrLoginThrottleNotFound         = errors.New("login throttle not found")
+	ErrAPIKeyNotFound                = errors.New("api key not found")
 )
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// Querier is the part of *sql.DB and *sql.Tx used to run statements.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx is a transaction opened by DB.BeginTx.
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// DB is the handle every store runs its statements on. It is either the connection
+// pool, or a transaction shared by all stores of a Storage created by WithTx.
+type DB interface {
+	Querier
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+// poolDB adapts *sql.DB to DB.
+type poolDB struct {
+	*sql.DB
+}
+
+func (p poolDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return p.DB.BeginTx(ctx, opts)
+}
+
+// txDB runs statements on an open transaction. Stores that begin their own transaction
+// on it get a savepoint instead, so their Commit and Rollback only affect their own
+// statements and the outer transaction decides the final outcome.
+type txDB struct {
+	*sql.Tx
+	savepoints *atomic.Int64
+}
+
+func (t txDB) BeginTx(ctx context.Context, _ *sql.TxOptions) (Tx, error) {
+	name := fmt.Sprintf("sp_%d", t.savepoints.Add(1))
+
+	if _, err := t.Tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+
+	return &savepointTx{tx: t.Tx, ctx: ctx, name: name}, nil
+}
+
+type savepointTx struct {
+	tx   *sql.Tx
+	ctx  context.Context
+	name string
+	done bool
+}
+
+func (s *savepointTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.tx.ExecContext(ctx, query, args...)
+}
+
+func (s *savepointTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.tx.QueryContext(ctx, query, args...)
+}
+
+func (s *savepointTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (s *savepointTx) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+
+	_, err := s.tx.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+// Rollback undoes the statements run since the savepoint. Like sql.Tx it is safe to
+// defer after Commit.
+func (s *savepointTx) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+
+	_, err := s.tx.ExecContext(context.WithoutCancel(s.ctx), "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
+
+// WithTx runs fn with a Storage whose repositories all share one transaction. The
+// transaction is committed if fn returns nil and rolled back otherwise. Calling WithTx
+// on a Storage that is already transactional runs fn within a savepoint of the same
+// transaction.
+func (s *Storage) WithTx(ctx context.Context, fn func(tx *Storage) error) error {
+	if t, ok := s.db.(txDB); ok {
+		sp, err := t.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer sp.Rollback()
+
+		if err := fn(s); err != nil {
+			return err
+		}
+		return sp.Commit()
+	}
+
+	pool, ok := s.db.(poolDB)
+	if !ok {
+		return fmt.Errorf("store: unsupported handle %T", s.db)
+	}
+
+	tx, err := pool.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(newStorage(txDB{Tx: tx, savepoints: &atomic.Int64{}})); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
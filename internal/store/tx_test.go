@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// recordingConnector is a database/sql driver that runs nothing and records every
+// statement and transaction boundary it is sent.
+type recordingConnector struct {
+	log []string
+}
+
+func (r *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{r}, nil
+}
+
+func (r *recordingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type recordingConn struct {
+	r *recordingConnector
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	c.r.log = append(c.r.log, "BEGIN")
+	return c, nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.r.log = append(c.r.log, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) Commit() error {
+	c.r.log = append(c.r.log, "COMMIT")
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.r.log = append(c.r.log, "ROLLBACK")
+	return nil
+}
+
+// handle returns the DB the repositories of s run their statements on.
+func handle(s *Storage) DB {
+	return s.Users.(*UserStore).db
+}
+
+func exec(t *testing.T, s *Storage, query string) {
+	t.Helper()
+
+	if _, err := handle(s).ExecContext(context.Background(), query); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		fn      func(t *testing.T, tx *Storage) error
+		wantErr error
+		wantLog []string
+	}{
+		{
+			name: "commits",
+			fn: func(t *testing.T, tx *Storage) error {
+				exec(t, tx, "A")
+				return nil
+			},
+			wantLog: []string{"BEGIN", "A", "COMMIT"},
+		},
+		{
+			name: "rolls back on error",
+			fn: func(t *testing.T, tx *Storage) error {
+				exec(t, tx, "A")
+				return errFailed
+			},
+			wantErr: errFailed,
+			wantLog: []string{"BEGIN", "A", "ROLLBACK"},
+		},
+		{
+			name: "nested call releases its savepoint",
+			fn: func(t *testing.T, tx *Storage) error {
+				exec(t, tx, "A")
+				return tx.WithTx(context.Background(), func(inner *Storage) error {
+					exec(t, inner, "B")
+					return nil
+				})
+			},
+			wantLog: []string{"BEGIN", "A", "SAVEPOINT sp_1", "B", "RELEASE SAVEPOINT sp_1", "COMMIT"},
+		},
+		{
+			name: "nested error only undoes the savepoint",
+			fn: func(t *testing.T, tx *Storage) error {
+				exec(t, tx, "A")
+				if err := tx.WithTx(context.Background(), func(inner *Storage) error {
+					exec(t, inner, "B")
+					return errFailed
+				}); !errors.Is(err, errFailed) {
+					t.Errorf("nested WithTx() = %v, want %v", err, errFailed)
+				}
+				exec(t, tx, "C")
+				return nil
+			},
+			wantLog: []string{"BEGIN", "A", "SAVEPOINT sp_1", "B", "ROLLBACK TO SAVEPOINT sp_1", "C", "COMMIT"},
+		},
+		{
+			name: "nested error returned rolls back everything",
+			fn: func(t *testing.T, tx *Storage) error {
+				return tx.WithTx(context.Background(), func(inner *Storage) error {
+					exec(t, inner, "B")
+					return errFailed
+				})
+			},
+			wantErr: errFailed,
+			wantLog: []string{"BEGIN", "SAVEPOINT sp_1", "B", "ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK"},
+		},
+		{
+			name: "savepoints nest",
+			fn: func(t *testing.T, tx *Storage) error {
+				return tx.WithTx(context.Background(), func(inner *Storage) error {
+					return inner.WithTx(context.Background(), func(innermost *Storage) error {
+						exec(t, innermost, "B")
+						return nil
+					})
+				})
+			},
+			wantLog: []string{"BEGIN", "SAVEPOINT sp_1", "SAVEPOINT sp_2", "B", "RELEASE SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_1", "COMMIT"},
+		},
+		{
+			name: "store transactions become savepoints",
+			fn: func(t *testing.T, tx *Storage) error {
+				ctx := context.Background()
+
+				committed, err := handle(tx).BeginTx(ctx, nil)
+				if err != nil {
+					return err
+				}
+				if _, err := committed.ExecContext(ctx, "A"); err != nil {
+					return err
+				}
+				if err := committed.Commit(); err != nil {
+					return err
+				}
+				// the deferred rollback after a commit is a no-op
+				if err := committed.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+					t.Errorf("Rollback() after Commit() = %v, want %v", err, sql.ErrTxDone)
+				}
+
+				rolledBack, err := tx.Packages.(*PackageStore).db.BeginTx(ctx, nil)
+				if err != nil {
+					return err
+				}
+				if _, err := rolledBack.ExecContext(ctx, "B"); err != nil {
+					return err
+				}
+				return rolledBack.Rollback()
+			},
+			wantLog: []string{"BEGIN", "SAVEPOINT sp_1", "A", "RELEASE SAVEPOINT sp_1", "SAVEPOINT sp_2", "B", "ROLLBACK TO SAVEPOINT sp_2", "COMMIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector := &recordingConnector{}
+			db := sql.OpenDB(connector)
+			defer db.Close()
+
+			err := NewStorage(db).WithTx(context.Background(), func(tx *Storage) error {
+				return tt.fn(t, tx)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithTx() = %v, want %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(connector.log, tt.wantLog) {
+				t.Errorf("statements = %q, want %q", connector.log, tt.wantLog)
+			}
+		})
+	}
+}
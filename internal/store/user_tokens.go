@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+const (
+	UserTokenPurposePasswordReset     = "password_reset"
+	UserTokenPurposeEmailVerification = "email_verification"
+)
+
+type UserTokenStore struct {
+	db DB
+}
+
+const userTokenColumns = `id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+func scanUserToken(row rowScanner, token *models.UserToken) error {
+	var usedAt sql.NullTime
+
+	if err := row.Scan(&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.ExpiresAt, &usedAt, &token.CreatedAt); err != nil {
+		return err
+	}
+
+	token.UsedAt = nil
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return nil
+}
+
+// CreateUserToken stores a token and discards the user's earlier unused tokens for the
+// same purpose, so only the latest emailed link works.
+func (u *UserTokenStore) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, token.UserID, token.Purpose); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING ` + userTokenColumns
+
+	if err = scanUserToken(tx.QueryRowContext(ctx, query, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt), token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeUserToken marks the token with the given purpose and hash as used and returns
+// it. Unknown, used and expired tokens all return ErrUserTokenInvalid.
+func (u *UserTokenStore) ConsumeUserToken(ctx context.Context, purpose, hash string) (*models.UserToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	token := &models.UserToken{}
+
+	query := `SELECT ` + userTokenColumns + ` FROM user_tokens WHERE purpose = $1 AND token_hash = $2 FOR UPDATE`
+
+	if err = scanUserToken(tx.QueryRowContext(ctx, query, purpose, hash), token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserTokenInvalid
+		}
+		return nil, err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrUserTokenInvalid
+	}
+
+	if err = scanUserToken(tx.QueryRowContext(ctx, `UPDATE user_tokens SET used_at = NOW() WHERE id = $1 RETURNING `+userTokenColumns, token.ID), token); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
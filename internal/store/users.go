@@ -3,19 +3,43 @@ package store
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/puremike/pcourierds/internal/listing"
 	"github.com/puremike/pcourierds/internal/models"
 )
 
 type UserStore struct {
-	db *sql.DB
+	db DB
+}
+
+const userColumns = `id, username, email, role, password, tokens_valid_after, email_verified_at, created_at`
+
+func scanUser(row rowScanner, user *models.User) error {
+	var tokensValidAfter, emailVerifiedAt sql.NullTime
+
+	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Password, &tokensValidAfter, &emailVerifiedAt, &user.CreatedAt); err != nil {
+		return err
+	}
+
+	user.TokensValidAfter = nil
+	if tokensValidAfter.Valid {
+		user.TokensValidAfter = &tokensValidAfter.Time
+	}
+
+	user.EmailVerifiedAt = nil
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+
+	return nil
 }
 
 func (u *UserStore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
-	query := `INSERT INTO users (username, email, role, password) VALUES ($1, $2, $3, $4) RETURNING id, username, email, role, created_at`
+	query := `INSERT INTO users (username, email, role, password, email_verified_at) VALUES ($1, $2, $3, $4, $5) RETURNING ` + userColumns
 
 	tx, err := u.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -24,7 +48,7 @@ func (u *UserStore) CreateUser(ctx context.Context, user *models.User) (*models.
 
 	defer tx.Rollback()
 
-	if err = tx.QueryRowContext(ctx, query, user.Username, user.Email, user.Role, user.Password).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
+	if err = scanUser(tx.QueryRowContext(ctx, query, user.Username, user.Email, user.Role, user.Password, user.EmailVerifiedAt), user); err != nil {
 		return nil, err
 	}
 
@@ -41,9 +65,9 @@ func (u *UserStore) GetUserById(ctx context.Context, id string) (*models.User, e
 
 	user := &models.User{}
 
-	query := `SELECT id, username, email, role, password, created_at FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
-	if err := u.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Password, &user.CreatedAt); err != nil {
+	if err := scanUser(u.db.QueryRowContext(ctx, query, id), user); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
 		}
@@ -59,9 +83,9 @@ func (u *UserStore) GetUserByEmail(ctx context.Context, email string) (*models.U
 
 	user := &models.User{}
 
-	query := `SELECT id, username, email, role, password, created_at FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
-	if err := u.db.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Password, &user.CreatedAt); err != nil {
+	if err := scanUser(u.db.QueryRowContext(ctx, query, email), user); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
 		}
@@ -71,11 +95,15 @@ func (u *UserStore) GetUserByEmail(ctx context.Context, email string) (*models.U
 	return user, nil
 }
 
+// UpdateUser updates the user's profile. Changing the email address clears its
+// verification.
 func (u *UserStore) UpdateUser(ctx context.Context, user *models.User, id string) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
-	query := `UPDATE users SET username = $1, email = $2, role = $3 WHERE id = $4 RETURNING id, username, email, role, created_at`
+	query := `UPDATE users SET username = $1, email = $2, role = $3,
+		email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
+		WHERE id = $4 RETURNING ` + userColumns
 
 	tx, err := u.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -84,8 +112,11 @@ func (u *UserStore) UpdateUser(ctx context.Context, user *models.User, id string
 
 	defer tx.Rollback()
 
-	if err = tx.QueryRowContext(ctx, query, user.Username, user.Email, user.Role, id).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
-
+	if err = scanUser(tx.QueryRowContext(ctx, query, user.Username, user.Email, user.Role, id), user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -117,34 +148,98 @@ func (u *UserStore) UpdatePassword(ctx context.Context, user *models.User, id st
 	return nil
 }
 
-func (u *UserStore) GetAllUsers(ctx context.Context) (*[]models.User, error) {
+// MarkEmailVerified records that the user has verified their email address. Verifying
+// again keeps the original time.
+func (u *UserStore) MarkEmailVerified(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1`
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return tx.Commit()
+}
+
+// UserListSpec is what GetAllUsers can sort and filter by.
+var UserListSpec = listing.Spec{
+	Sorts:        []string{"created_at", "username", "email"},
+	Filters:      []string{"role"},
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+var userListColumns = listColumns{
+	sorts:     map[string]string{"created_at": "created_at", "username": "username", "email": "email"},
+	filters:   map[string]string{"role": "role"},
+	search:    []string{"username ILIKE %[1]s", "email ILIKE %[1]s"},
+	createdAt: "created_at",
+	id:        "id",
+}
+
+func (u *UserStore) GetAllUsers(ctx context.Context, q *listing.Query) (*[]models.User, *listing.Page, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
 	defer cancel()
 
-	query := `SELECT id, username, email, role, created_at FROM users`
+	query, args := buildListQuery(`SELECT id, username, email, role, email_verified_at, created_at FROM users`, q, userListColumns)
 
 	var users []models.User
 
-	rows, err := u.db.QueryContext(ctx, query)
+	rows, err := u.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var u models.User
-		if err = rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.CreatedAt); err != nil {
-			return nil, err
+		var emailVerifiedAt sql.NullTime
+		if err = rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &emailVerifiedAt, &u.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+
+		if emailVerifiedAt.Valid {
+			u.EmailVerifiedAt = &emailVerifiedAt.Time
 		}
 
 		users = append(users, u)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &users, nil
+	users, page := finishPage(q, users, func(u *models.User) (string, string) {
+		switch q.Sort {
+		case "username":
+			return u.Username, u.ID
+		case "email":
+			return u.Email, u.ID
+		default:
+			return u.CreatedAt.Format(time.RFC3339Nano), u.ID
+		}
+	})
+
+	return &users, page, nil
 }
 
 func (u *UserStore) DeleteUserById(ctx context.Context, id string) error {
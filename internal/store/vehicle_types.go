@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/puremike/pcourierds/internal/models"
+)
+
+type VehicleTypeStore struct {
+	db DB
+}
+
+const vehicleTypeColumns = `code, name, max_weight_kg, max_volume_l, avg_speed_kmh, pricing_multiplier, is_active, created_at, updated_at`
+
+func scanVehicleType(row rowScanner, vehicle *models.VehicleType) error {
+	return row.Scan(&vehicle.Code, &vehicle.Name, &vehicle.MaxWeightKg, &vehicle.MaxVolumeL, &vehicle.AvgSpeedKmh, &vehicle.PricingMultiplier, &vehicle.IsActive, &vehicle.CreatedAt, &vehicle.UpdatedAt)
+}
+
+func (v *VehicleTypeStore) GetVehicleType(ctx context.Context, code string) (*models.VehicleType, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	vehicle := &models.VehicleType{}
+
+	query := `SELECT ` + vehicleTypeColumns + ` FROM vehicle_types WHERE code = $1`
+
+	if err := scanVehicleType(v.db.QueryRowContext(ctx, query, code), vehicle); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVehicleTypeNotFound
+		}
+		return nil, err
+	}
+
+	return vehicle, nil
+}
+
+func (v *VehicleTypeStore) GetAllVehicleTypes(ctx context.Context) (*[]models.VehicleType, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `SELECT ` + vehicleTypeColumns + ` FROM vehicle_types ORDER BY max_weight_kg, code`
+
+	vehicles := []models.VehicleType{}
+
+	rows, err := v.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var vt models.VehicleType
+		if err = scanVehicleType(rows, &vt); err != nil {
+			return nil, err
+		}
+
+		vehicles = append(vehicles, vt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &vehicles, nil
+}
+
+func (v *VehicleTypeStore) UpsertVehicleType(ctx context.Context, vehicle *models.VehicleType) (*models.VehicleType, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	query := `INSERT INTO vehicle_types (code, name, max_weight_kg, max_volume_l, avg_speed_kmh, pricing_multiplier, is_active) VALUES ($1, $2, $3, $4, $5, $6, $7)
+              ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, max_weight_kg = EXCLUDED.max_weight_kg, max_volume_l = EXCLUDED.max_volume_l, avg_speed_kmh = EXCLUDED.avg_speed_kmh, pricing_multiplier = EXCLUDED.pricing_multiplier, is_active = EXCLUDED.is_active, updated_at = NOW()
+              RETURNING ` + vehicleTypeColumns
+
+	tx, err := v.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if err = scanVehicleType(tx.QueryRowContext(ctx, query, vehicle.Code, vehicle.Name, vehicle.MaxWeightKg, vehicle.MaxVolumeL, vehicle.AvgSpeedKmh, vehicle.PricingMultiplier, vehicle.IsActive), vehicle); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return vehicle, nil
+}
+
+// DeleteVehicleType removes a vehicle type and its pricing. Types still used by an
+// application, dispatcher or package cannot be deleted and should be deactivated instead.
+func (v *VehicleTypeStore) DeleteVehicleType(ctx context.Context, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryBackgroundTimeout)
+	defer cancel()
+
+	tx, err := v.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM vehicle_types WHERE code = $1`, code)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrVehicleTypeInUse
+		}
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrVehicleTypeNotFound
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,73 @@
+package tracking
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// alphabet excludes characters that are easy to misread (0, 1, I, L, O).
+const alphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+const (
+	prefix     = "PCD"
+	bodyLength = 7 // random characters, followed by one check character
+)
+
+// NewCode returns a random tracking code in the form PCD-XXXX-XXXX, where the last
+// character is a check character over the other seven.
+func NewCode() (string, error) {
+	body := make([]byte, bodyLength)
+	max := big.NewInt(int64(len(alphabet)))
+
+	for i := range body {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		body[i] = alphabet[n.Int64()]
+	}
+
+	raw := string(body) + string(checkChar(string(body)))
+	return prefix + "-" + raw[:4] + "-" + raw[4:], nil
+}
+
+// Normalize upper-cases a code and strips surrounding whitespace.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Valid reports whether code is well formed and its check character matches.
+func Valid(code string) bool {
+	parts := strings.Split(code, "-")
+	if len(parts) != 3 || parts[0] != prefix || len(parts[1]) != 4 || len(parts[2]) != 4 {
+		return false
+	}
+
+	raw := parts[1] + parts[2]
+	for i := 0; i < len(raw); i++ {
+		if strings.IndexByte(alphabet, raw[i]) < 0 {
+			return false
+		}
+	}
+
+	return checkChar(raw[:bodyLength]) == raw[bodyLength]
+}
+
+// checkChar computes a weighted mod 31 check character: the i-th character of the
+// code, check character included, is weighted by i+1 and the weighted sum must be a
+// multiple of 31. As 31 is prime and every weight and every difference of adjacent
+// weights is non-zero mod 31, every single character typo and every adjacent
+// transposition is caught. (Luhn mod N only guarantees this for an even N.)
+func checkChar(body string) byte {
+	n := len(alphabet)
+	sum := 0
+
+	for i := 0; i < len(body); i++ {
+		sum += (i + 1) * strings.IndexByte(alphabet, body[i])
+	}
+
+	// solve sum + (bodyLength+1)*c = 0 (mod n) for c, using the inverse of 8 mod 31
+	const checkWeightInverse = 4
+	return alphabet[(n-(sum*checkWeightInverse)%n)%n]
+}
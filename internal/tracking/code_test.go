@@ -0,0 +1,88 @@
+package tracking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCodeIsValid(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		code, err := NewCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != len("PCD-XXXX-XXXX") || !strings.HasPrefix(code, prefix+"-") {
+			t.Fatalf("NewCode() = %q, want PCD-XXXX-XXXX", code)
+		}
+		if !Valid(code) {
+			t.Fatalf("Valid(%q) = false for a generated code", code)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	body := "7K3MQ9X"
+	code := prefix + "-" + body[:4] + "-" + body[4:] + string(checkChar(body))
+
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"generated", code, true},
+		{"normalized lower case", Normalize(" " + strings.ToLower(code) + " "), true},
+		{"lower case", strings.ToLower(code), false},
+		{"wrong prefix", "ABC" + code[3:], false},
+		{"missing dash", strings.Replace(code, "-", "", 1), false},
+		{"short group", code[:len(code)-1], false},
+		{"excluded character", code[:4] + "0" + code[5:], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid(tt.code); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidRejectsSingleCharacterTypos swaps every character of a code, check
+// character included, for every other character of the alphabet.
+func TestValidRejectsSingleCharacterTypos(t *testing.T) {
+	for _, body := range []string{"7K3MQ9X", "2222222", "ZZZZZZZ", "23456789"[:bodyLength]} {
+		raw := body + string(checkChar(body))
+
+		for i := 0; i < len(raw); i++ {
+			for j := 0; j < len(alphabet); j++ {
+				if alphabet[j] == raw[i] {
+					continue
+				}
+
+				typo := raw[:i] + string(alphabet[j]) + raw[i+1:]
+				code := prefix + "-" + typo[:4] + "-" + typo[4:]
+				if Valid(code) {
+					t.Errorf("Valid(%q) = true, typo of %s-%s-%s", code, prefix, raw[:4], raw[4:])
+				}
+			}
+		}
+	}
+}
+
+func TestValidRejectsAdjacentTranspositions(t *testing.T) {
+	for _, body := range []string{"7K3MQ9X", "23456789"[:bodyLength], "ZY2XW3V"} {
+		raw := body + string(checkChar(body))
+
+		for i := 0; i+1 < len(raw); i++ {
+			if raw[i] == raw[i+1] {
+				continue
+			}
+
+			swapped := raw[:i] + string(raw[i+1]) + string(raw[i]) + raw[i+2:]
+			code := prefix + "-" + swapped[:4] + "-" + swapped[4:]
+			if Valid(code) {
+				t.Errorf("Valid(%q) = true, transposition of %s-%s-%s", code, prefix, raw[:4], raw[4:])
+			}
+		}
+	}
+}
@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS idx_packages_dispatcher_id;
+
+DROP INDEX IF EXISTS idx_packages_user_id;
+
+ALTER TABLE packages
+DROP COLUMN weight_kg;
+
+ALTER TABLE packages
+DROP COLUMN description;
+
+ALTER TABLE packages
+DROP COLUMN recipient_phone;
+
+ALTER TABLE packages
+DROP COLUMN recipient_name;
+
+-- Packages could not exist without a dispatcher before this migration, so unassigned
+-- ones are removed to let the NOT NULL constraint be restored
+DELETE FROM packages WHERE dispatcher_id IS NULL;
+
+ALTER TABLE packages
+ALTER COLUMN dispatcher_id SET NOT NULL;
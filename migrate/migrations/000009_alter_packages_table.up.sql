@@ -0,0 +1,20 @@
+-- A package is booked before a dispatcher is picked for it
+ALTER TABLE packages
+ALTER COLUMN dispatcher_id DROP NOT NULL;
+
+-- Add recipient and parcel details
+ALTER TABLE packages
+ADD COLUMN recipient_name TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE packages
+ADD COLUMN recipient_phone TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE packages
+ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE packages
+ADD COLUMN weight_kg DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (weight_kg >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_packages_user_id ON packages (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_packages_dispatcher_id ON packages (dispatcher_id);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS package_status_events_immutable ON package_status_events;
+
+DROP FUNCTION IF EXISTS prevent_package_status_events_change();
+
+DROP TABLE IF EXISTS package_status_events;
+
+ALTER TABLE packages
+DROP CONSTRAINT IF EXISTS check_package_status;
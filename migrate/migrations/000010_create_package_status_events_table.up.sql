@@ -0,0 +1,37 @@
+-- Ensure package status follows the lifecycle enforced by the store
+ALTER TABLE packages
+ADD CONSTRAINT check_package_status CHECK (status IN ('created', 'awaiting_pickup', 'picked_up', 'in_transit', 'out_for_delivery', 'delivered', 'failed', 'cancelled', 'returned'));
+
+-- PACKAGE STATUS EVENTS
+CREATE TABLE IF NOT EXISTS package_status_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    package_id UUID NOT NULL,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    actor_id UUID,
+    location TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (package_id) REFERENCES packages(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_package_status_events_package_id ON package_status_events (package_id, created_at);
+
+-- Status events are append-only. Changes are only allowed when they cascade
+-- from a deleted package or user (trigger depth > 1).
+CREATE OR REPLACE FUNCTION prevent_package_status_events_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF pg_trigger_depth() > 1 THEN
+        IF TG_OP = 'DELETE' THEN
+            RETURN OLD;
+        END IF;
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'package_status_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER package_status_events_immutable
+BEFORE UPDATE OR DELETE ON package_status_events
+FOR EACH ROW EXECUTE FUNCTION prevent_package_status_events_change();
@@ -0,0 +1,8 @@
+ALTER TABLE packages
+DROP COLUMN estimated_delivery_at;
+
+ALTER TABLE packages
+DROP CONSTRAINT IF EXISTS packages_tracking_code_key;
+
+ALTER TABLE packages
+DROP COLUMN tracking_code;
@@ -0,0 +1,10 @@
+-- Public tracking code, generated on package creation
+ALTER TABLE packages
+ADD COLUMN tracking_code TEXT;
+
+ALTER TABLE packages
+ADD CONSTRAINT packages_tracking_code_key UNIQUE (tracking_code);
+
+-- Estimated delivery time shown on the public tracking page
+ALTER TABLE packages
+ADD COLUMN estimated_delivery_at TIMESTAMP;
@@ -0,0 +1,33 @@
+ALTER TABLE packages
+DROP COLUMN currency;
+
+ALTER TABLE packages
+DROP COLUMN price;
+
+ALTER TABLE packages
+DROP COLUMN height_cm;
+
+ALTER TABLE packages
+DROP COLUMN width_cm;
+
+ALTER TABLE packages
+DROP COLUMN length_cm;
+
+ALTER TABLE packages
+DROP COLUMN destination_lng;
+
+ALTER TABLE packages
+DROP COLUMN destination_lat;
+
+ALTER TABLE packages
+DROP COLUMN origin_lng;
+
+ALTER TABLE packages
+DROP COLUMN origin_lat;
+
+ALTER TABLE packages
+DROP COLUMN vehicle_type;
+
+DROP TABLE IF EXISTS pricing_weight_tiers;
+
+DROP TABLE IF EXISTS pricing_rates;
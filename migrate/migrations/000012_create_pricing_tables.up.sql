@@ -0,0 +1,67 @@
+-- PRICING RATES (amounts in the currency's minor unit)
+CREATE TABLE IF NOT EXISTS pricing_rates (
+    vehicle_type TEXT PRIMARY KEY,
+    currency TEXT NOT NULL DEFAULT 'NGN',
+    base_fare BIGINT NOT NULL CHECK (base_fare >= 0),
+    per_km BIGINT NOT NULL CHECK (per_km >= 0),
+    per_kg BIGINT NOT NULL CHECK (per_kg >= 0),
+    min_fare BIGINT NOT NULL DEFAULT 0 CHECK (min_fare >= 0),
+    volumetric_divisor INTEGER NOT NULL DEFAULT 5000 CHECK (volumetric_divisor >= 0),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- PRICING WEIGHT TIERS, covering (min_weight_kg, max_weight_kg]
+CREATE TABLE IF NOT EXISTS pricing_weight_tiers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_type TEXT NOT NULL,
+    min_weight_kg DOUBLE PRECISION NOT NULL CHECK (min_weight_kg >= 0),
+    max_weight_kg DOUBLE PRECISION NOT NULL,
+    surcharge BIGINT NOT NULL DEFAULT 0 CHECK (surcharge >= 0),
+    CHECK (max_weight_kg > min_weight_kg),
+    FOREIGN KEY (vehicle_type) REFERENCES pricing_rates(vehicle_type) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_weight_tiers_vehicle_type ON pricing_weight_tiers (vehicle_type);
+
+INSERT INTO pricing_rates (vehicle_type, currency, base_fare, per_km, per_kg, min_fare) VALUES
+    ('motorcycle', 'NGN', 80000, 12000, 8000, 100000),
+    ('car', 'NGN', 150000, 20000, 5000, 200000)
+ON CONFLICT (vehicle_type) DO NOTHING;
+
+INSERT INTO pricing_weight_tiers (vehicle_type, min_weight_kg, max_weight_kg, surcharge) VALUES
+    ('motorcycle', 0, 5, 0),
+    ('motorcycle', 5, 15, 50000),
+    ('car', 0, 10, 0),
+    ('car', 10, 30, 100000),
+    ('car', 30, 50, 250000);
+
+-- Quoted details and the price a package was booked at
+ALTER TABLE packages
+ADD COLUMN vehicle_type TEXT NOT NULL DEFAULT 'car';
+
+ALTER TABLE packages
+ADD COLUMN origin_lat DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN origin_lng DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN destination_lat DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN destination_lng DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN length_cm DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN width_cm DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN height_cm DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN price BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE packages
+ADD COLUMN currency TEXT NOT NULL DEFAULT 'NGN';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_packages_dispatcher_id_status;
+
+DROP INDEX IF EXISTS idx_dispatchers_isactive;
+
+DROP TABLE IF EXISTS dispatcher_last_positions;
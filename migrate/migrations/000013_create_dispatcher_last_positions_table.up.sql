@@ -0,0 +1,12 @@
+-- Last known position of each dispatcher, used to rank dispatchers by distance to pickup
+CREATE TABLE IF NOT EXISTS dispatcher_last_positions (
+    dispatcher_id UUID PRIMARY KEY,
+    lat DOUBLE PRECISION NOT NULL CHECK (lat BETWEEN -90 AND 90),
+    lng DOUBLE PRECISION NOT NULL CHECK (lng BETWEEN -180 AND 180),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (dispatcher_id) REFERENCES dispatchers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispatchers_isactive ON dispatchers (isactive);
+
+CREATE INDEX IF NOT EXISTS idx_packages_dispatcher_id_status ON packages (dispatcher_id, status);
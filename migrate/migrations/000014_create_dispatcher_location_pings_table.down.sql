@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_dispatcher_last_positions_lat_lng;
+
+ALTER TABLE dispatcher_last_positions
+DROP COLUMN recorded_at;
+
+ALTER TABLE dispatcher_last_positions
+DROP COLUMN speed_mps;
+
+ALTER TABLE dispatcher_last_positions
+DROP COLUMN heading;
+
+ALTER TABLE dispatcher_last_positions
+DROP COLUMN accuracy_m;
+
+DROP TABLE IF EXISTS dispatcher_location_pings;
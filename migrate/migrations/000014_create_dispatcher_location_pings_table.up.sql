@@ -0,0 +1,30 @@
+-- Raw GPS pings sent by dispatcher devices
+CREATE TABLE IF NOT EXISTS dispatcher_location_pings (
+    id BIGSERIAL PRIMARY KEY,
+    dispatcher_id UUID NOT NULL,
+    lat DOUBLE PRECISION NOT NULL CHECK (lat BETWEEN -90 AND 90),
+    lng DOUBLE PRECISION NOT NULL CHECK (lng BETWEEN -180 AND 180),
+    accuracy_m DOUBLE PRECISION,
+    heading DOUBLE PRECISION,
+    speed_mps DOUBLE PRECISION,
+    recorded_at TIMESTAMP NOT NULL,
+    received_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (dispatcher_id) REFERENCES dispatchers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispatcher_location_pings_dispatcher_id ON dispatcher_location_pings (dispatcher_id, recorded_at);
+
+-- Keep the device details of the newest ping alongside the last known position
+ALTER TABLE dispatcher_last_positions
+ADD COLUMN accuracy_m DOUBLE PRECISION;
+
+ALTER TABLE dispatcher_last_positions
+ADD COLUMN heading DOUBLE PRECISION;
+
+ALTER TABLE dispatcher_last_positions
+ADD COLUMN speed_mps DOUBLE PRECISION;
+
+ALTER TABLE dispatcher_last_positions
+ADD COLUMN recorded_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_dispatcher_last_positions_lat_lng ON dispatcher_last_positions (lat, lng);
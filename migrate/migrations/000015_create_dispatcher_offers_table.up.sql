@@ -0,0 +1,19 @@
+-- DISPATCHER OFFERS
+CREATE TABLE IF NOT EXISTS dispatcher_offers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    package_id UUID NOT NULL,
+    dispatcher_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'expired', 'cancelled')),
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (package_id) REFERENCES packages(id) ON DELETE CASCADE,
+    FOREIGN KEY (dispatcher_id) REFERENCES dispatchers(id) ON DELETE CASCADE
+);
+
+-- A package is offered to one dispatcher at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispatcher_offers_pending_package ON dispatcher_offers (package_id) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_dispatcher_offers_dispatcher_id ON dispatcher_offers (dispatcher_id, status);
+
+CREATE INDEX IF NOT EXISTS idx_dispatcher_offers_pending_expiry ON dispatcher_offers (expires_at) WHERE status = 'pending';
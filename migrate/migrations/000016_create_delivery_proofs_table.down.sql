@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS delivery_proofs;
+
+ALTER TABLE packages DROP COLUMN IF EXISTS delivery_pin_attempts;
+
+ALTER TABLE packages DROP COLUMN IF EXISTS delivery_pin_hash;
@@ -0,0 +1,24 @@
+-- The recipient's one-time delivery PIN is only ever stored hashed
+ALTER TABLE packages ADD COLUMN IF NOT EXISTS delivery_pin_hash TEXT;
+
+ALTER TABLE packages ADD COLUMN IF NOT EXISTS delivery_pin_attempts INT NOT NULL DEFAULT 0;
+
+-- DELIVERY PROOFS
+CREATE TABLE IF NOT EXISTS delivery_proofs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    package_id UUID NOT NULL UNIQUE,
+    method TEXT NOT NULL CHECK (method IN ('pin', 'signature_photo')),
+    signature_key TEXT,
+    signature_content_type TEXT,
+    photo_key TEXT,
+    photo_content_type TEXT,
+    delivered_by UUID,
+    location TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (package_id) REFERENCES packages(id) ON DELETE CASCADE,
+    FOREIGN KEY (delivered_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT check_delivery_proof_attachments CHECK (
+        method = 'pin' OR (signature_key IS NOT NULL AND photo_key IS NOT NULL)
+    )
+);
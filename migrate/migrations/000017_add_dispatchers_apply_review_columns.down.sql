@@ -0,0 +1,21 @@
+DROP INDEX IF EXISTS idx_dispatchers_apply_user_created_at;
+
+DROP INDEX IF EXISTS idx_dispatchers_apply_active_user;
+
+ALTER TABLE dispatchers_apply
+DROP CONSTRAINT IF EXISTS check_rejection_reason;
+
+ALTER TABLE dispatchers_apply
+DROP CONSTRAINT IF EXISTS check_status;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS review_note;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS rejection_reason;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS reviewed_at;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS reviewed_by;
@@ -0,0 +1,25 @@
+-- Keep reviewed applications instead of deleting rejected ones
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS rejection_reason TEXT;
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS review_note TEXT NOT NULL DEFAULT '';
+
+-- Ensure status has valid values
+ALTER TABLE dispatchers_apply
+ADD CONSTRAINT check_status CHECK (status IN ('pending', 'approved', 'rejected'));
+
+-- A rejection always carries its reason
+ALTER TABLE dispatchers_apply
+ADD CONSTRAINT check_rejection_reason CHECK (status <> 'rejected' OR rejection_reason IS NOT NULL);
+
+-- Rejected applications are kept, so a user may have several but only one open or approved
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispatchers_apply_active_user ON dispatchers_apply (user_id) WHERE status IN ('pending', 'approved');
+
+CREATE INDEX IF NOT EXISTS idx_dispatchers_apply_user_created_at ON dispatchers_apply (user_id, created_at DESC);
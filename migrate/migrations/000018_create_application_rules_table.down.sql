@@ -0,0 +1,7 @@
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS vehicle_make;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS region;
+
+DROP TABLE IF EXISTS application_rules;
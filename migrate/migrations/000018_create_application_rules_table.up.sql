@@ -0,0 +1,21 @@
+-- APPLICATION RULES: dispatcher application requirements per region
+CREATE TABLE IF NOT EXISTS application_rules (
+    region TEXT PRIMARY KEY,
+    plate_pattern TEXT NOT NULL DEFAULT '',
+    license_pattern TEXT NOT NULL DEFAULT '',
+    min_vehicle_year INT NOT NULL DEFAULT 0,
+    max_vehicle_age_years INT NOT NULL DEFAULT 0 CHECK (max_vehicle_age_years >= 0),
+    allowed_makes TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- The checks that used to be hardcoded at review time
+INSERT INTO application_rules (region, plate_pattern, license_pattern, min_vehicle_year)
+VALUES ('default', '.{8}', '.{12}', 2008)
+ON CONFLICT (region) DO NOTHING;
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS region TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS vehicle_make TEXT NOT NULL DEFAULT '';
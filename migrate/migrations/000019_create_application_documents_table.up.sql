@@ -0,0 +1,16 @@
+-- APPLICATION DOCUMENTS: files supporting a dispatcher application, one per kind
+CREATE TABLE IF NOT EXISTS application_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('driver_license', 'vehicle_registration', 'insurance_certificate', 'selfie')),
+    blob_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    sha256 TEXT NOT NULL,
+    uploaded_by UUID,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (application_id) REFERENCES dispatchers_apply(id) ON DELETE CASCADE,
+    FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT application_documents_application_kind_key UNIQUE (application_id, kind)
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS application_history;
+
+UPDATE dispatchers_apply SET status = 'rejected', rejection_reason = 'other' WHERE status = 'withdrawn';
+
+ALTER TABLE dispatchers_apply
+DROP CONSTRAINT IF EXISTS check_status;
+
+ALTER TABLE dispatchers_apply
+ADD CONSTRAINT check_status CHECK (status IN ('pending', 'approved', 'rejected'));
@@ -0,0 +1,21 @@
+-- Applicants can withdraw their own application
+ALTER TABLE dispatchers_apply
+DROP CONSTRAINT IF EXISTS check_status;
+
+ALTER TABLE dispatchers_apply
+ADD CONSTRAINT check_status CHECK (status IN ('pending', 'approved', 'rejected', 'withdrawn'));
+
+-- APPLICATION HISTORY: every submission, edit, withdrawal and review of an application
+CREATE TABLE IF NOT EXISTS application_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL,
+    actor_id UUID,
+    action TEXT NOT NULL CHECK (action IN ('submitted', 'edited', 'withdrawn', 'approved', 'rejected')),
+    changes JSONB NOT NULL DEFAULT '{}',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (application_id) REFERENCES dispatchers_apply(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_history_application_id ON application_history (application_id, created_at);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_dispatchers_apply_status_created_at;
+
+DROP INDEX IF EXISTS idx_dispatchers_apply_created_at_id;
+
+DROP INDEX IF EXISTS idx_users_email_id;
+
+DROP INDEX IF EXISTS idx_users_username_id;
+
+DROP INDEX IF EXISTS idx_users_created_at_id;
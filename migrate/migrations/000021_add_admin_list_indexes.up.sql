@@ -0,0 +1,10 @@
+-- Keyset pagination orders by (sort column, id)
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_users_username_id ON users (username, id);
+
+CREATE INDEX IF NOT EXISTS idx_users_email_id ON users (email, id);
+
+CREATE INDEX IF NOT EXISTS idx_dispatchers_apply_created_at_id ON dispatchers_apply (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_dispatchers_apply_status_created_at ON dispatchers_apply (status, created_at, id);
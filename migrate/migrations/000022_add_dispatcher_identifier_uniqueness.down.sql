@@ -0,0 +1,31 @@
+DROP TRIGGER IF EXISTS dispatchers_identifiers ON dispatchers;
+
+DROP TRIGGER IF EXISTS dispatchers_apply_identifiers ON dispatchers_apply;
+
+DROP FUNCTION IF EXISTS check_dispatcher_identifiers();
+
+DROP INDEX IF EXISTS idx_dispatchers_apply_license_lookup;
+
+DROP INDEX IF EXISTS idx_dispatchers_apply_plate_lookup;
+
+DROP INDEX IF EXISTS idx_dispatchers_license_normalized;
+
+DROP INDEX IF EXISTS idx_dispatchers_plate_normalized;
+
+DROP INDEX IF EXISTS idx_dispatchers_apply_license_normalized;
+
+DROP INDEX IF EXISTS idx_dispatchers_apply_plate_normalized;
+
+ALTER TABLE dispatchers
+DROP COLUMN IF EXISTS license_normalized;
+
+ALTER TABLE dispatchers
+DROP COLUMN IF EXISTS plate_normalized;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS license_normalized;
+
+ALTER TABLE dispatchers_apply
+DROP COLUMN IF EXISTS plate_normalized;
+
+DROP FUNCTION IF EXISTS normalize_identifier(TEXT);
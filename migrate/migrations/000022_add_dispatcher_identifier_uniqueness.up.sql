@@ -0,0 +1,126 @@
+-- Plate numbers and driver licenses are compared without case, spaces or punctuation
+CREATE OR REPLACE FUNCTION normalize_identifier(value TEXT) RETURNS TEXT AS $$
+    SELECT upper(regexp_replace(coalesce(value, ''), '[^[:alnum:]]', '', 'g'));
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS plate_normalized TEXT GENERATED ALWAYS AS (normalize_identifier(vehicle_plate_number)) STORED;
+
+ALTER TABLE dispatchers_apply
+ADD COLUMN IF NOT EXISTS license_normalized TEXT GENERATED ALWAYS AS (normalize_identifier(driver_license)) STORED;
+
+ALTER TABLE dispatchers
+ADD COLUMN IF NOT EXISTS plate_normalized TEXT GENERATED ALWAYS AS (normalize_identifier(vehicle_plate_number)) STORED;
+
+ALTER TABLE dispatchers
+ADD COLUMN IF NOT EXISTS license_normalized TEXT GENERATED ALWAYS AS (normalize_identifier(driver_license)) STORED;
+
+-- Existing duplicates would fail the unique indexes below with a bare index error.
+-- List them up front instead; they have to be resolved by hand (reject or withdraw
+-- the extra applications, or correct the dispatcher records) before migrating again.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%s %s held by %s', kind, value, holders), '; ')
+    INTO duplicates
+    FROM (
+        SELECT 'application plate' AS kind, plate_normalized AS value, string_agg(id::text, ', ') AS holders
+        FROM dispatchers_apply
+        WHERE status IN ('pending', 'approved') AND plate_normalized <> ''
+        GROUP BY plate_normalized HAVING count(*) > 1
+        UNION ALL
+        SELECT 'application license', license_normalized, string_agg(id::text, ', ')
+        FROM dispatchers_apply
+        WHERE status IN ('pending', 'approved') AND license_normalized <> ''
+        GROUP BY license_normalized HAVING count(*) > 1
+        UNION ALL
+        SELECT 'dispatcher plate', plate_normalized, string_agg(id::text, ', ')
+        FROM dispatchers
+        WHERE plate_normalized <> ''
+        GROUP BY plate_normalized HAVING count(*) > 1
+        UNION ALL
+        SELECT 'dispatcher license', license_normalized, string_agg(id::text, ', ')
+        FROM dispatchers
+        WHERE license_normalized <> ''
+        GROUP BY license_normalized HAVING count(*) > 1
+    ) found;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate dispatcher identifiers must be resolved before this migration: %', duplicates
+            USING HINT = 'reject or withdraw the extra applications, or correct the dispatcher records, then migrate again';
+    END IF;
+END;
+$$;
+
+-- Only open and approved applications hold an identifier; rejected and withdrawn ones
+-- are kept for the near-duplicate report.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispatchers_apply_plate_normalized ON dispatchers_apply (plate_normalized) WHERE status IN ('pending', 'approved') AND plate_normalized <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispatchers_apply_license_normalized ON dispatchers_apply (license_normalized) WHERE status IN ('pending', 'approved') AND license_normalized <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispatchers_plate_normalized ON dispatchers (plate_normalized) WHERE plate_normalized <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dispatchers_license_normalized ON dispatchers (license_normalized) WHERE license_normalized <> '';
+
+CREATE INDEX IF NOT EXISTS idx_dispatchers_apply_plate_lookup ON dispatchers_apply (plate_normalized);
+
+CREATE INDEX IF NOT EXISTS idx_dispatchers_apply_license_lookup ON dispatchers_apply (license_normalized);
+
+-- An identifier held by one user's application or dispatcher record may not be used by
+-- another user in the other table. The advisory locks serialise concurrent inserts into
+-- the two tables, which no index can cover. Conflicts are raised as unique violations
+-- so the store maps them like the indexes above.
+CREATE OR REPLACE FUNCTION check_dispatcher_identifiers() RETURNS TRIGGER AS $$
+DECLARE
+    plate_taken BOOLEAN;
+    license_taken BOOLEAN;
+BEGIN
+    IF TG_TABLE_NAME = 'dispatchers_apply' AND NEW.status NOT IN ('pending', 'approved') THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_advisory_xact_lock(hashtext('dispatcher_plate:' || NEW.plate_normalized));
+    PERFORM pg_advisory_xact_lock(hashtext('dispatcher_license:' || NEW.license_normalized));
+
+    IF TG_TABLE_NAME = 'dispatchers_apply' THEN
+        SELECT
+            bool_or(plate_normalized = NEW.plate_normalized),
+            bool_or(license_normalized = NEW.license_normalized)
+        INTO plate_taken, license_taken
+        FROM dispatchers
+        WHERE user_id <> NEW.user_id
+          AND (plate_normalized = NEW.plate_normalized OR license_normalized = NEW.license_normalized);
+    ELSE
+        SELECT
+            bool_or(plate_normalized = NEW.plate_normalized),
+            bool_or(license_normalized = NEW.license_normalized)
+        INTO plate_taken, license_taken
+        FROM dispatchers_apply
+        WHERE user_id <> NEW.user_id
+          AND status IN ('pending', 'approved')
+          AND (plate_normalized = NEW.plate_normalized OR license_normalized = NEW.license_normalized);
+    END IF;
+
+    IF NEW.plate_normalized <> '' AND plate_taken THEN
+        RAISE EXCEPTION 'vehicle plate number % is already registered', NEW.vehicle_plate_number
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'dispatcher_plate_taken';
+    END IF;
+
+    IF NEW.license_normalized <> '' AND license_taken THEN
+        RAISE EXCEPTION 'driver license % is already registered', NEW.driver_license
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'dispatcher_license_taken';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- AFTER triggers see the generated columns
+CREATE TRIGGER dispatchers_apply_identifiers
+AFTER INSERT OR UPDATE OF vehicle_plate_number, driver_license, status ON dispatchers_apply
+FOR EACH ROW EXECUTE FUNCTION check_dispatcher_identifiers();
+
+CREATE TRIGGER dispatchers_identifiers
+AFTER INSERT OR UPDATE OF vehicle_plate_number, driver_license, user_id ON dispatchers
+FOR EACH ROW EXECUTE FUNCTION check_dispatcher_identifiers();
@@ -0,0 +1,15 @@
+ALTER TABLE pricing_rates
+DROP CONSTRAINT IF EXISTS fk_pricing_rates_vehicle_type;
+
+ALTER TABLE packages
+DROP CONSTRAINT IF EXISTS fk_packages_vehicle_type;
+
+ALTER TABLE dispatchers
+DROP CONSTRAINT IF EXISTS fk_dispatchers_vehicle_type;
+
+ALTER TABLE dispatchers_apply
+DROP CONSTRAINT IF EXISTS fk_dispatchers_apply_vehicle_type;
+
+DELETE FROM pricing_rates WHERE vehicle_type IN ('bicycle', 'van', 'truck');
+
+DROP TABLE IF EXISTS vehicle_types;
@@ -0,0 +1,65 @@
+-- VEHICLE TYPES: the vehicles dispatchers deliver with and what each can carry
+CREATE TABLE IF NOT EXISTS vehicle_types (
+    code TEXT PRIMARY KEY CHECK (code ~ '^[a-z][a-z0-9_]*$'),
+    name TEXT NOT NULL,
+    max_weight_kg DOUBLE PRECISION NOT NULL CHECK (max_weight_kg > 0),
+    max_volume_l DOUBLE PRECISION NOT NULL CHECK (max_volume_l > 0),
+    avg_speed_kmh DOUBLE PRECISION NOT NULL CHECK (avg_speed_kmh > 0),
+    pricing_multiplier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (pricing_multiplier > 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO vehicle_types (code, name, max_weight_kg, max_volume_l, avg_speed_kmh) VALUES
+    ('bicycle', 'Bicycle', 8, 40, 15),
+    ('motorcycle', 'Motorcycle', 15, 80, 30),
+    ('car', 'Car', 50, 400, 25),
+    ('van', 'Van', 800, 6000, 22),
+    ('truck', 'Truck', 5000, 30000, 18)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO pricing_rates (vehicle_type, currency, base_fare, per_km, per_kg, min_fare) VALUES
+    ('bicycle', 'NGN', 50000, 10000, 8000, 70000),
+    ('van', 'NGN', 400000, 35000, 2000, 500000),
+    ('truck', 'NGN', 1200000, 60000, 1000, 1500000)
+ON CONFLICT (vehicle_type) DO NOTHING;
+
+INSERT INTO pricing_weight_tiers (vehicle_type, min_weight_kg, max_weight_kg, surcharge)
+SELECT v.vehicle_type, v.min_weight_kg, v.max_weight_kg, v.surcharge FROM (VALUES
+    ('bicycle', 0, 8, 0),
+    ('van', 0, 200, 0),
+    ('van', 200, 800, 300000),
+    ('truck', 0, 2000, 0),
+    ('truck', 2000, 5000, 800000)
+) AS v (vehicle_type, min_weight_kg, max_weight_kg, surcharge)
+WHERE NOT EXISTS (SELECT 1 FROM pricing_weight_tiers t WHERE t.vehicle_type = v.vehicle_type);
+
+-- Vehicle types used before the catalogue existed are kept so the foreign keys hold
+INSERT INTO vehicle_types (code, name, max_weight_kg, max_volume_l, avg_speed_kmh, is_active)
+SELECT DISTINCT t.vehicle_type, t.vehicle_type, 50, 400, 25, FALSE FROM (
+    SELECT vehicle_type FROM dispatchers_apply
+    UNION SELECT vehicle_type FROM dispatchers
+    UNION SELECT vehicle_type FROM packages
+    UNION SELECT vehicle_type FROM pricing_rates
+) t
+ON CONFLICT (code) DO NOTHING;
+
+-- The catalogue replaces the hardcoded car/motorcycle checks
+ALTER TABLE dispatchers_apply
+DROP CONSTRAINT IF EXISTS dispatchers_apply_vehicle_type_check;
+
+ALTER TABLE dispatchers
+DROP CONSTRAINT IF EXISTS dispatchers_vehicle_type_check;
+
+ALTER TABLE dispatchers_apply
+ADD CONSTRAINT fk_dispatchers_apply_vehicle_type FOREIGN KEY (vehicle_type) REFERENCES vehicle_types(code) ON UPDATE CASCADE;
+
+ALTER TABLE dispatchers
+ADD CONSTRAINT fk_dispatchers_vehicle_type FOREIGN KEY (vehicle_type) REFERENCES vehicle_types(code) ON UPDATE CASCADE;
+
+ALTER TABLE packages
+ADD CONSTRAINT fk_packages_vehicle_type FOREIGN KEY (vehicle_type) REFERENCES vehicle_types(code) ON UPDATE CASCADE;
+
+ALTER TABLE pricing_rates
+ADD CONSTRAINT fk_pricing_rates_vehicle_type FOREIGN KEY (vehicle_type) REFERENCES vehicle_types(code) ON UPDATE CASCADE ON DELETE CASCADE;
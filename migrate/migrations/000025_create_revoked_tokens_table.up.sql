@@ -0,0 +1,15 @@
+-- REVOKED TOKENS: access tokens logged out before they expire, by jti claim. Rows are
+-- only needed until the token would have expired anyway.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Revoking every token of a user rejects access tokens issued before this time
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP;
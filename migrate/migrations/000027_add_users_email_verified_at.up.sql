@@ -0,0 +1,11 @@
+-- Accounts created before email verification existed are treated as verified
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+ALTER TABLE user_tokens
+DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+
+ALTER TABLE user_tokens
+ADD CONSTRAINT user_tokens_purpose_check CHECK (purpose IN ('password_reset', 'email_verification'));
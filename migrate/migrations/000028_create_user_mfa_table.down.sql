@@ -0,0 +1,6 @@
+ALTER TABLE refresh_tokens
+DROP COLUMN IF EXISTS mfa_verified;
+
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+DROP TABLE IF EXISTS user_mfa;
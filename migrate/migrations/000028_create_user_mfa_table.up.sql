@@ -0,0 +1,27 @@
+-- USER MFA: a user's TOTP authenticator. The secret is encrypted by the API before it
+-- is stored. enabled_at stays NULL until enrollment is confirmed with a code.
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id UUID PRIMARY KEY,
+    secret_ciphertext TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT, -- last accepted TOTP time step, so codes cannot be replayed
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- MFA RECOVERY CODES: single-use codes for when the authenticator is lost. Only hashes
+-- are stored.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Refresh tokens carry over whether the login completed two-factor authentication
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS mfa_verified BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,12 @@
+-- LOGIN THROTTLES: recent failed logins per account (lowercased email) and per client
+-- IP. Logins are refused until locked_until. Unknown emails are tracked too, so
+-- lockouts do not reveal which accounts exist.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    kind TEXT NOT NULL CHECK (kind IN ('account', 'ip')),
+    subject TEXT NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP,
+    locked_until TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (kind, subject)
+);
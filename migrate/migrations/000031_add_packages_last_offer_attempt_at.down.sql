@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_packages_awaiting_offer;
+
+ALTER TABLE packages DROP COLUMN IF EXISTS last_offer_attempt_at;
@@ -0,0 +1,6 @@
+-- last_offer_attempt_at: when the package was last offered, or found no dispatcher.
+-- The offer sweeper retries the least recently attempted packages first so packages
+-- nobody can take do not starve newer ones.
+ALTER TABLE packages ADD COLUMN IF NOT EXISTS last_offer_attempt_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_packages_awaiting_offer ON packages (last_offer_attempt_at NULLS FIRST, created_at) WHERE status = 'created' AND dispatcher_id IS NULL;
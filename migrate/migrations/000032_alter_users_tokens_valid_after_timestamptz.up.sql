@@ -0,0 +1,3 @@
+-- tokens_valid_after is compared with the UTC iat claim of access tokens, so it has to
+-- be an instant rather than a wall clock time in whatever zone the writer used.
+ALTER TABLE users ALTER COLUMN tokens_valid_after TYPE TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Lets failed logins clear rows whose failures have been forgotten without a full scan
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure_at ON login_throttles (kind, last_failure_at);
@@ -0,0 +1,4 @@
+ALTER TABLE login_throttles
+    ALTER COLUMN last_failure_at TYPE TIMESTAMP,
+    ALTER COLUMN locked_until TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
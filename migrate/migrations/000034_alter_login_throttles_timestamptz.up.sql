@@ -0,0 +1,6 @@
+-- Lock times are written by the application and compared with its clock, so they have
+-- to be instants rather than wall clock times in whatever zone the writer used.
+ALTER TABLE login_throttles
+    ALTER COLUMN last_failure_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN locked_until TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
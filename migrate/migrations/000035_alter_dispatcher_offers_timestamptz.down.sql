@@ -0,0 +1,4 @@
+ALTER TABLE dispatcher_offers
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN responded_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
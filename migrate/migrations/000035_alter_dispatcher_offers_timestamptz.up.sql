@@ -0,0 +1,6 @@
+-- Offer expiry is compared with both the database and the application clock, so the
+-- times have to be instants rather than wall clock times in the writer's zone.
+ALTER TABLE dispatcher_offers
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN responded_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
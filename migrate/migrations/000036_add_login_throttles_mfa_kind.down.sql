@@ -0,0 +1,6 @@
+DELETE FROM login_throttles WHERE kind = 'mfa';
+
+ALTER TABLE login_throttles DROP CONSTRAINT IF EXISTS login_throttles_kind_check;
+
+ALTER TABLE login_throttles
+ADD CONSTRAINT login_throttles_kind_check CHECK (kind IN ('account', 'ip'));
@@ -0,0 +1,6 @@
+-- Two-factor code attempts per user are throttled in the same table, so the limit holds
+-- across every instance
+ALTER TABLE login_throttles DROP CONSTRAINT IF EXISTS login_throttles_kind_check;
+
+ALTER TABLE login_throttles
+ADD CONSTRAINT login_throttles_kind_check CHECK (kind IN ('account', 'ip', 'mfa'));
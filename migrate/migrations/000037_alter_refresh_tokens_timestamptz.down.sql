@@ -0,0 +1,5 @@
+ALTER TABLE refresh_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN used_at TYPE TIMESTAMP,
+    ALTER COLUMN revoked_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
@@ -0,0 +1,4 @@
+ALTER TABLE user_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN used_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
@@ -0,0 +1,6 @@
+-- Expiry is compared with the application clock, so the times have to be instants rather
+-- than wall clock times in whatever zone the writer used.
+ALTER TABLE user_tokens
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN used_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+ALTER TABLE api_keys
+    ALTER COLUMN last_used_at TYPE TIMESTAMP,
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN revoked_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
@@ -0,0 +1,7 @@
+-- Expiry is compared with the application clock, so the times have to be instants rather
+-- than wall clock times in whatever zone the writer used.
+ALTER TABLE api_keys
+    ALTER COLUMN last_used_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- recipient_email: where the recipient's delivery PIN is sent at booking. Empty when
+-- the sender did not give one, in which case the sender passes the PIN on.
+ALTER TABLE packages ADD COLUMN IF NOT EXISTS recipient_email TEXT NOT NULL DEFAULT '';